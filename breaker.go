@@ -0,0 +1,83 @@
+package hotellook
+
+import (
+	"net/http"
+	"time"
+)
+
+// State of circuit breaker of one endpoint.
+type breakerState struct {
+	// Consecutive failures, circuit is open when it reaches threshold.
+	failures int
+	openedAt time.Time
+	// Set while the only request of half-open circuit is in flight.
+	probing bool
+}
+
+// Makes requests to an endpoint fail with ErrCircuitOpen without reaching
+// API after threshold consecutive failures (network errors and 5xx
+// responses). After coolDown one request is let through: its success closes
+// the circuit, failure opens it for another coolDown. Zero threshold
+// disables the breaker, which is the default.
+func (this *API) SetCircuitBreaker(threshold int, coolDown time.Duration) {
+	this.mu.Lock()
+	this.breakerThreshold = threshold
+	this.breakerCoolDown = coolDown
+	this.breakers = nil
+	this.mu.Unlock()
+}
+
+// Returns ErrCircuitOpen if request to endpoint should not be made.
+func (this *API) breakerAllow(endpoint string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	b := this.breakers[endpoint]
+	if this.breakerThreshold <= 0 || b == nil || b.failures < this.breakerThreshold {
+		return nil
+	}
+	if b.probing || this.now().Before(b.openedAt.Add(this.breakerCoolDown)) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// Records result of request allowed by breakerAllow.
+func (this *API) breakerDone(endpoint string, r *http.Response, err error) {
+	failed := err != nil || r.StatusCode >= http.StatusInternalServerError
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.breakerThreshold <= 0 {
+		return
+	}
+	b := this.breakers[endpoint]
+	if b == nil {
+		if !failed {
+			return
+		}
+		if this.breakers == nil {
+			this.breakers = make(map[string]*breakerState)
+		}
+		b = new(breakerState)
+		this.breakers[endpoint] = b
+	}
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= this.breakerThreshold {
+		b.openedAt = this.now()
+	}
+}
+
+// Lets another request probe half-open circuit, when request was cancelled
+// by caller and tells nothing about API.
+func (this *API) breakerRelease(endpoint string) {
+	this.mu.Lock()
+	if b := this.breakers[endpoint]; b != nil {
+		b.probing = false
+	}
+	this.mu.Unlock()
+}
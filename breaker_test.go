@@ -0,0 +1,70 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	calls := 0
+	status := http.StatusBadGateway
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		resp := mockResponse(`[]`)
+		resp.StatusCode = status
+		return resp, nil
+	})
+	c := newFakeClock()
+	api.setClock(c)
+	api.SetCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.Countries(); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("circuit is open after %d failures", i)
+		}
+	}
+	if _, err := api.Countries(); !errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Fatalf("got %v after %d calls, expected ErrCircuitOpen without request", err, calls)
+	}
+	if _, err := api.Lookup(&LookupRequest{Query: "moscow"}); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("circuit of another endpoint is open")
+	}
+
+	// Failed probe opens circuit again.
+	c.now = c.now.Add(time.Minute)
+	api.Countries()
+	if _, err := api.Countries(); !errors.Is(err, ErrCircuitOpen) || calls != 5 {
+		t.Fatalf("got %v after %d calls, expected one failed probe", err, calls)
+	}
+
+	c.now = c.now.Add(time.Minute)
+	status = http.StatusOK
+	if _, err := api.Countries(); err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := api.Countries(); err != nil {
+			t.Fatalf("got %v, expected closed circuit after successful probe", err)
+		}
+	}
+	if calls != 9 {
+		t.Fatalf("got %d calls, expected 9", calls)
+	}
+}
+
+func TestCircuitBreakerCancelled(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return nil, r.Context().Err()
+	})
+	api.SetCircuitBreaker(1, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := api.lookup(ctx, &LookupRequest{Query: "moscow"}); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("requests cancelled by caller opened circuit")
+		}
+	}
+}
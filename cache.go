@@ -0,0 +1,254 @@
+package hotellook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Names of static datasets.
+const (
+	StaticCountries = "countries"
+	StaticCities    = "cities"
+	StaticAmenities = "amenities"
+	StaticRoomTypes = "roomTypes"
+)
+
+var staticNames = []string{StaticCountries, StaticCities, StaticAmenities, StaticRoomTypes}
+
+// Raw response of static endpoint.
+type staticData struct {
+	body    []byte
+	fetched time.Time
+	// See StaticVersion.
+	version string
+}
+
+// Static methods (Countries, Cities, Amenities, RoomTypes) will fetch
+// their data only once and then decode it from memory.
+func (this *API) EnableStaticCache() {
+	this.mu.Lock()
+	if this.static == nil {
+		this.static = make(map[string]*staticData)
+	}
+	this.mu.Unlock()
+}
+
+// Enables static cache and concurrently fetches all static datasets into it.
+func (this *API) WarmStaticCache(ctx context.Context) error {
+	if err := this.checkAccess(); err != nil {
+		return err
+	}
+	this.EnableStaticCache()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, name := range staticNames {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			var v interface{}
+			if err := this.fetchStatic(ctx, name, &v); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Returns how long ago static dataset was cached, false if it's not cached.
+func (this *API) StaticCacheAge(name string) (time.Duration, bool) {
+	this.mu.Lock()
+	cached := this.static[name]
+	this.mu.Unlock()
+	if cached == nil {
+		return 0, false
+	}
+	return this.now().Sub(cached.fetched), true
+}
+
+// Enables static cache and fetches static dataset into it again.
+func (this *API) RefreshStatic(ctx context.Context, name string) error {
+	if !isStatic(name) {
+		return ErrUnknownEndpoint
+	}
+	if err := this.checkAccess(); err != nil {
+		return err
+	}
+	this.EnableStaticCache()
+	var v interface{}
+	return this.loadStatic(ctx, name, &v)
+}
+
+func isStatic(name string) bool {
+	for _, n := range staticNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Decodes static dataset into v, using cache if it's enabled.
+func (this *API) fetchStatic(ctx context.Context, name string, v interface{}) error {
+	this.mu.Lock()
+	cached := this.static[name]
+	this.mu.Unlock()
+	if cached != nil {
+		return this.decode(cached.body, v)
+	}
+	return this.loadStatic(ctx, name, v)
+}
+
+// Requests static dataset and decodes it into v. Body is cached
+// only if it was decoded, otherwise it's probably an error response.
+func (this *API) loadStatic(ctx context.Context, name string, v interface{}) error {
+	if name == StaticCities {
+		if err := this.waitForRateLimit(ctx, citiesMinRemains); err != nil {
+			return err
+		}
+	}
+	body, header, err := this.getWithHeader(ctx, name, this.withSignature(nil), "")
+	if err != nil {
+		return err
+	}
+	if err = this.decode(body, v); err != nil {
+		if errors.Is(err, ErrUnexpectedField) {
+			return wrapErr(name, err)
+		}
+		return wrapErr(name, ErrNoAccess)
+	}
+
+	this.mu.Lock()
+	if this.static != nil {
+		this.static[name] = &staticData{body: body, fetched: this.now(), version: staticVersion(header)}
+	}
+	this.resetIndex(name)
+	this.mu.Unlock()
+	return nil
+}
+
+// Drops index built from static dataset, so it's built again from refreshed
+// data. Must be called with mu locked.
+func (this *API) resetIndex(name string) {
+	switch name {
+	case StaticCountries:
+		this.countries = nil
+	case StaticAmenities:
+		this.amenityGroups = nil
+	case StaticCities:
+		this.cityCodes = nil
+	}
+}
+
+// Returns version of cached static dataset from the response it was cached
+// from: ETag, or Last-Modified if server didn't send ETag. Empty string means
+// that dataset is not cached or server sent neither header.
+// Changed version means that reference data changed, e.g. indexes
+// built from it should be rebuilt.
+func (this *API) StaticVersion(name string) string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if cached := this.static[name]; cached != nil {
+		return cached.version
+	}
+	return ""
+}
+
+func staticVersion(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// Metadata of saved static dataset, kept in <name>.meta.json next to it.
+type staticMeta struct {
+	Fetched time.Time `json:"fetched"`
+	Version string    `json:"version,omitempty"`
+}
+
+// Writes cached static datasets into dir as <name>.json files, the moment
+// dataset was fetched and its version (see StaticVersion) are written into
+// <name>.meta.json. Datasets which are not cached are skipped.
+func (this *API) SaveStaticCache(dir string) error {
+	this.mu.Lock()
+	cached := make(map[string]*staticData, len(this.static))
+	for name, data := range this.static {
+		cached[name] = data
+	}
+	this.mu.Unlock()
+
+	for name, data := range cached {
+		if err := writeFile(filepath.Join(dir, name+".json"), data.body); err != nil {
+			return err
+		}
+		meta, err := json.Marshal(staticMeta{Fetched: data.fetched, Version: data.version})
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, name+".meta.json"), meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes data to temporary file first, so concurrent LoadStaticCache
+// never reads a partial file.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Enables static cache and fills it with datasets saved by SaveStaticCache.
+// Datasets with missing data or metadata file are skipped, so StaticCacheAge
+// tells which datasets are still to be fetched or are too old.
+func (this *API) LoadStaticCache(dir string) error {
+	this.EnableStaticCache()
+	for _, name := range staticNames {
+		body, err := ioutil.ReadFile(filepath.Join(dir, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		rawMeta, err := ioutil.ReadFile(filepath.Join(dir, name+".meta.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var meta staticMeta
+		if !json.Valid(body) || json.Unmarshal(rawMeta, &meta) != nil {
+			return wrapErr(name, ErrInvalidJSON)
+		}
+
+		this.mu.Lock()
+		this.static[name] = &staticData{body: body, fetched: meta.Fetched, version: meta.Version}
+		this.resetIndex(name)
+		this.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Returns API which serves static endpoints with empty lists and counts
+// requests by path.
+func staticMockAPI() (*API, map[string]int) {
+	calls := make(map[string]int)
+	mu := new(sync.Mutex)
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls[strings.TrimPrefix(r.URL.Path, "/api/v2/")]++
+		mu.Unlock()
+		return mockResponse(`[]`), nil
+	})
+	return api, calls
+}
+
+func TestWarmStaticCache(t *testing.T) {
+	api, calls := staticMockAPI()
+	if err := api.WarmStaticCache(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(calls) != len(staticNames) {
+		t.Fatalf("got calls %v, expected every static endpoint", calls)
+	}
+
+	api.Countries()
+	api.Cities()
+	api.Amenities()
+	api.RoomTypes()
+	for path, n := range calls {
+		if n != 1 {
+			t.Fatalf("%s was called %d times, expected once", path, n)
+		}
+	}
+}
+
+func TestWarmStaticCacheErrors(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		resp := mockResponse(`{"status":"error","message":"Unauthorized"}`)
+		resp.StatusCode = http.StatusUnauthorized
+		return resp, nil
+	})
+	err := api.WarmStaticCache(context.Background())
+	var e *APIError
+	if !errors.Is(err, ErrNoAccess) || !errors.As(err, &e) {
+		t.Fatalf("got %v, expected *APIError matching ErrNoAccess", err)
+	}
+	if strings.Contains(err.Error(), "roomTypes: hotellook: roomTypes") {
+		t.Fatalf("error %q has doubled endpoint prefix", err.Error())
+	}
+}
+
+func TestStaticCacheDisabled(t *testing.T) {
+	api, calls := staticMockAPI()
+	api.Countries()
+	api.Countries()
+	if calls["static/countries.json"] != 2 {
+		t.Fatal("static data should not be cached unless cache is enabled")
+	}
+}
+
+func TestRefreshStatic(t *testing.T) {
+	c := newFakeClock()
+	api, calls := staticMockAPI()
+	api.setClock(c)
+	if _, ok := api.StaticCacheAge(StaticCountries); ok {
+		t.Fatal("age of not cached dataset should not be reported")
+	}
+
+	api.EnableStaticCache()
+	api.Countries()
+	c.now = c.now.Add(time.Hour)
+	if age, ok := api.StaticCacheAge(StaticCountries); !ok || age != time.Hour {
+		t.Fatalf("got age %v (%v), expected 1h", age, ok)
+	}
+
+	if err := api.RefreshStatic(context.Background(), StaticCountries); err != nil {
+		t.Fatal(err.Error())
+	}
+	if calls["static/countries.json"] != 2 {
+		t.Fatalf("countries were fetched %d times, expected refresh", calls["static/countries.json"])
+	}
+	if age, _ := api.StaticCacheAge(StaticCountries); age != 0 {
+		t.Fatalf("got age %v after refresh, expected 0", age)
+	}
+	if err := api.RefreshStatic(context.Background(), "hotels"); err != ErrUnknownEndpoint {
+		t.Fatalf("got %v, expected ErrUnknownEndpoint", err)
+	}
+}
+
+func TestRefreshStaticIndex(t *testing.T) {
+	name := "France"
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[{"id":"2","code":"FR","EN":[{"name":"` + name + `"}]}]`), nil
+	})
+	if c, err := api.CountryByCode(context.Background(), "FR"); err != nil || c.EN[0].Name != "France" {
+		t.Fatalf("got %+v, %v, expected France", c, err)
+	}
+
+	name = "French Republic"
+	if err := api.RefreshStatic(context.Background(), StaticCountries); err != nil {
+		t.Fatal(err.Error())
+	}
+	c, err := api.CountryByCode(context.Background(), "FR")
+	if err != nil || c.EN[0].Name != "French Republic" {
+		t.Fatalf("got %+v, %v after refresh, expected renamed country", c, err)
+	}
+}
+
+func TestSaveStaticCache(t *testing.T) {
+	dir := t.TempDir()
+	c := newFakeClock()
+	api, _ := staticMockAPI()
+	api.setClock(c)
+	api.EnableStaticCache()
+	api.Countries()
+	api.Amenities()
+	if err := api.SaveStaticCache(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded, calls := staticMockAPI()
+	loaded.setClock(c)
+	c.now = c.now.Add(time.Hour)
+	// Copied files get new modification time, it's not the fetch time.
+	os.Chtimes(filepath.Join(dir, "countries.json"), c.now, c.now)
+	if err := loaded.LoadStaticCache(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	if age, ok := loaded.StaticCacheAge(StaticCountries); !ok || age != time.Hour {
+		t.Fatalf("got age %v (%v) of loaded countries, expected 1h", age, ok)
+	}
+	if _, ok := loaded.StaticCacheAge(StaticCities); ok {
+		t.Fatal("cities were not saved, but loaded")
+	}
+	if _, err := loaded.Countries(); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded.Amenities()
+	if len(calls) != 0 {
+		t.Fatalf("got calls %v, expected loaded datasets to be used", calls)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "countries.json"), []byte(`[{"id":`), 0644)
+	if err := loaded.LoadStaticCache(dir); !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("got %v, expected ErrInvalidJSON for broken file", err)
+	}
+}
+
+func TestStaticVersion(t *testing.T) {
+	etag := `"v1"`
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		resp := mockResponse(`[]`)
+		if etag != "" {
+			resp.Header.Set("ETag", etag)
+		} else {
+			resp.Header.Set("Last-Modified", "Sat, 10 Dec 2016 12:00:00 GMT")
+		}
+		return resp, nil
+	})
+	api.Countries()
+	if v := api.StaticVersion(StaticCountries); v != "" {
+		t.Fatalf("version %q of not cached dataset, expected none", v)
+	}
+
+	api.EnableStaticCache()
+	api.Countries()
+	if v := api.StaticVersion(StaticCountries); v != `"v1"` {
+		t.Fatalf("got version %q, expected ETag", v)
+	}
+	if v := api.StaticVersion(StaticAmenities); v != "" {
+		t.Fatalf("version %q of not fetched dataset", v)
+	}
+	etag = `"v2"`
+	api.Countries()
+	if v := api.StaticVersion(StaticCountries); v != `"v1"` {
+		t.Fatalf("got version %q, expected version of cached body", v)
+	}
+	api.RefreshStatic(context.Background(), StaticCountries)
+	if v := api.StaticVersion(StaticCountries); v != `"v2"` {
+		t.Fatalf("got version %q after refresh, expected \"v2\"", v)
+	}
+	etag = ""
+	api.RefreshStatic(context.Background(), StaticCountries)
+	if v := api.StaticVersion(StaticCountries); v != "Sat, 10 Dec 2016 12:00:00 GMT" {
+		t.Fatalf("got version %q, expected Last-Modified without ETag", v)
+	}
+
+	dir := t.TempDir()
+	if err := api.SaveStaticCache(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded := NewAPI(marker)
+	if err := loaded.LoadStaticCache(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	if v := loaded.StaticVersion(StaticCountries); v != api.StaticVersion(StaticCountries) {
+		t.Fatalf("loaded version %q, expected saved one", v)
+	}
+}
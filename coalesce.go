@@ -0,0 +1,71 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request which is being performed now.
+type flight struct {
+	// Closed when request is finished.
+	done   chan struct{}
+	body   []byte
+	header http.Header
+	err    error
+	// Set if request failed because its caller gave up, other callers
+	// should not get that error.
+	cancelled bool
+	// Number of callers waiting for this request.
+	dups int
+}
+
+// Concurrent calls with the same parameters (e.g. Lookup of the same query)
+// will share one HTTP request and its result. Useful under bursty load,
+// since every request consumes rate limit.
+func (this *API) EnableRequestCoalescing() {
+	this.mu.Lock()
+	if this.flights == nil {
+		this.flights = make(map[string]*flight)
+	}
+	this.mu.Unlock()
+}
+
+// Calls fn, unless the same key is in flight already, in that case
+// waits for it and returns its result. Waiting stops when ctx is done.
+// If the caller which performs request gave up, one of the waiting
+// callers performs it again.
+func (this *API) coalesce(ctx context.Context, key string, fn func() ([]byte, http.Header, error)) ([]byte, http.Header, error) {
+	for {
+		this.mu.Lock()
+		f, ok := this.flights[key]
+		if !ok {
+			break
+		}
+		f.dups++
+		this.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			this.mu.Lock()
+			f.dups--
+			this.mu.Unlock()
+			return nil, nil, ctx.Err()
+		}
+		if !f.cancelled {
+			return f.body, f.header, f.err
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	this.flights[key] = f
+	this.mu.Unlock()
+
+	f.body, f.header, f.err = fn()
+	f.cancelled = f.err != nil && ctx.Err() != nil
+
+	this.mu.Lock()
+	delete(this.flights, key)
+	this.mu.Unlock()
+	close(f.done)
+	return f.body, f.header, f.err
+}
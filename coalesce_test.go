@@ -0,0 +1,161 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	const n = 10
+	var calls int32
+	release := make(chan struct{})
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+	api.EnableRequestCoalescing()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := api.Lookup(&LookupRequest{Query: "moscow", Lang: "en"})
+			if err == nil && resp.Status != "ok" {
+				t.Error("unexpected status " + resp.Status)
+			}
+			errs <- err
+		}()
+	}
+
+	// Wait until all callers except the first one are waiting for it.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		api.mu.Lock()
+		dups := 0
+		for _, f := range api.flights {
+			dups = f.dups
+		}
+		api.mu.Unlock()
+		if dups == n-1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d callers joined the flight", dups)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("transport was called %d times, expected once", calls)
+	}
+	if len(api.flights) != 0 {
+		t.Fatal("finished request was not removed from flights")
+	}
+}
+
+// Waits until that many callers wait for the request in flight.
+func waitForDups(t *testing.T, api *API, dups int) {
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		api.mu.Lock()
+		n := -1
+		for _, f := range api.flights {
+			n = f.dups
+		}
+		api.mu.Unlock()
+		if n == dups {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d waiting callers, expected %d", n, dups)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescingWaiterCancel(t *testing.T) {
+	release := make(chan struct{})
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		<-release
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+	api.EnableRequestCoalescing()
+	req := &LookupRequest{Query: "moscow", Lang: "en"}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := api.lookup(context.Background(), req)
+		first <- err
+	}()
+	waitForDups(t, api, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := api.lookup(ctx, req)
+		waiter <- err
+	}()
+	waitForDups(t, api, 1)
+	cancel()
+	if err := <-waiter; !errors.Is(err, context.Canceled) {
+		t.Fatalf("waiter got %v after cancel, expected context.Canceled", err)
+	}
+	waitForDups(t, api, 0)
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestCoalescingFirstCallerCancel(t *testing.T) {
+	var calls int32
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+	api.EnableRequestCoalescing()
+	req := &LookupRequest{Query: "moscow", Lang: "en"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := api.lookup(ctx, req)
+		first <- err
+	}()
+	waitForDups(t, api, 0)
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := api.lookup(context.Background(), req)
+		waiter <- err
+	}()
+	waitForDups(t, api, 1)
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller got %v, expected context.Canceled", err)
+	}
+	if err := <-waiter; err != nil {
+		t.Fatalf("waiter got %v, expected request to be performed again", err)
+	}
+	if calls != 2 {
+		t.Fatalf("transport was called %d times, expected twice", calls)
+	}
+}
@@ -0,0 +1,37 @@
+package hotellook
+
+import (
+	"context"
+	"sync"
+)
+
+// Limits number of requests in flight to n for all methods of API, other
+// requests wait for a free slot or until their context is done. Request
+// holds its slot until response body is read. Zero n removes the limit.
+func (this *API) SetMaxConcurrency(n int) {
+	this.mu.Lock()
+	if n > 0 {
+		this.slots = make(chan struct{}, n)
+	} else {
+		this.slots = nil
+	}
+	this.mu.Unlock()
+}
+
+// Takes a slot of concurrency limit, release should be called once request
+// is finished. Release is nil if there is no limit.
+func (this *API) acquire(ctx context.Context) (release func(), err error) {
+	this.mu.Lock()
+	slots := this.slots
+	this.mu.Unlock()
+	if slots == nil {
+		return nil, nil
+	}
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-slots }) }, nil
+}
@@ -0,0 +1,69 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return mockResponse(`[]`), nil
+	})
+	api.SetMaxConcurrency(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.Countries(); err != nil {
+				t.Error(err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+	if maxInFlight > 2 {
+		t.Fatalf("got %d requests in flight, expected at most 2", maxInFlight)
+	}
+}
+
+func TestMaxConcurrencyContext(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[]`), nil
+	})
+	api.SetMaxConcurrency(1)
+	r, err := api.do(context.Background(), StaticCountries, "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// The only slot is held until body is closed.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = api.do(ctx, StaticCountries, "", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected request to wait for slot until deadline", err)
+	}
+	r.Body.Close()
+	r.Body.Close()
+	if _, err = api.Countries(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = api.Countries(); err != nil {
+		t.Fatal("slot is not released after request: " + err.Error())
+	}
+}
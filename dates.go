@@ -0,0 +1,17 @@
+package hotellook
+
+import "time"
+
+// Layout of CheckIn and CheckOut dates. Dates are local to the hotel,
+// they are not converted between timezones by API.
+const DateLayout = "2006-01-02"
+
+// Formats t as check-in or check-out date in timezone of the hotel, e.g.
+// midnight in Moscow is still the previous day in UTC. Nil loc keeps
+// location of t.
+func FormatDateInLocation(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(DateLayout)
+}
@@ -0,0 +1,28 @@
+package hotellook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDateInLocation(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+	cases := []struct {
+		t        time.Time
+		loc      *time.Location
+		expected string
+	}{
+		{time.Date(2016, 12, 9, 21, 0, 0, 0, time.UTC), moscow, "2016-12-10"},
+		{time.Date(2016, 12, 9, 20, 59, 59, 0, time.UTC), moscow, "2016-12-09"},
+		{time.Date(2016, 12, 10, 0, 0, 0, 0, moscow), time.UTC, "2016-12-09"},
+		{time.Date(2016, 12, 10, 0, 30, 0, 0, time.UTC), newYork, "2016-12-09"},
+		{time.Date(2016, 12, 31, 23, 0, 0, 0, newYork), moscow, "2017-01-01"},
+		{time.Date(2016, 12, 10, 0, 0, 0, 0, moscow), nil, "2016-12-10"},
+	}
+	for _, c := range cases {
+		if d := FormatDateInLocation(c.t, c.loc); d != c.expected {
+			t.Fatalf("FormatDateInLocation(%v, %v) = %s, expected %s", c.t, c.loc, d, c.expected)
+		}
+	}
+}
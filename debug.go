@@ -0,0 +1,51 @@
+package hotellook
+
+import (
+	"context"
+	"log"
+	"net/url"
+)
+
+// Makes every request check that its signature matches its params, which
+// is recomputed locally. Missing or wrong signatures are logged as warnings
+// with logger set by SetLogger, or with standard logger. For debugging only.
+func (this *API) SetDebugSignatures(enabled bool) {
+	this.mu.Lock()
+	this.debugSignatures = enabled
+	this.mu.Unlock()
+}
+
+func (this *API) checkSignature(ctx context.Context, endpoint, query string) {
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		this.warn(ctx, "hotellook: %s: query can't be parsed: %v", endpoint, err)
+		return
+	}
+	sent := q.Get("signature")
+	if sent == "" {
+		this.warn(ctx, "hotellook: %s: request is not signed", endpoint)
+		return
+	}
+	params := make(map[string]string)
+	for k := range q {
+		if k != "marker" && k != "signature" {
+			params[k] = q.Get(k)
+		}
+	}
+	expected, _ := url.ParseQuery(this.withSignature(params))
+	if sent != expected.Get("signature") {
+		this.warn(ctx, "hotellook: %s: signature %s doesn't match params, expected %s",
+			endpoint, sent, expected.Get("signature"))
+	}
+}
+
+func (this *API) warn(ctx context.Context, format string, args ...interface{}) {
+	this.mu.Lock()
+	logger := this.logger
+	this.mu.Unlock()
+	if logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	logger(ctx, format, args...)
+}
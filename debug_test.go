@@ -0,0 +1,51 @@
+package hotellook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugSignatures(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[]`), nil
+	})
+	var warnings []string
+	api.SetLogger(func(ctx context.Context, format string, args ...interface{}) {
+		// Requests are logged as well.
+		if msg := fmt.Sprintf(format, args...); strings.Contains(msg, "sign") {
+			warnings = append(warnings, msg)
+		}
+	})
+	api.SetDebugSignatures(true)
+	request := func(query string) {
+		if r, err := api.do(context.Background(), EndpointHotels, query, ""); err == nil {
+			r.Body.Close()
+		}
+	}
+
+	query := api.withSignature(map[string]string{"locationId": "12153"})
+	request(query)
+	if len(warnings) != 0 {
+		t.Fatalf("got warnings %q for valid signature", warnings)
+	}
+
+	// Param is added after signing.
+	request(query + "&lang=en")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "doesn't match") {
+		t.Fatalf("got warnings %q, expected one for broken signature", warnings)
+	}
+
+	request("locationId=12153")
+	if len(warnings) != 2 || !strings.Contains(warnings[1], "not signed") {
+		t.Fatalf("got warnings %q, expected one for missing signature", warnings)
+	}
+
+	api.SetDebugSignatures(false)
+	request(query + "&lang=en")
+	if len(warnings) != 2 {
+		t.Fatalf("got warnings %q with debug disabled", warnings)
+	}
+}
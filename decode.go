@@ -0,0 +1,42 @@
+package hotellook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pquerna/ffjson/ffjson"
+)
+
+// Makes responses with fields unknown to this package fail with
+// ErrUnexpectedField, to notice changes of API schema early. Lenient by
+// default, strict mode is meant for tests.
+func (this *API) SetStrictJSON(enabled bool) {
+	this.mu.Lock()
+	this.strictJSON = enabled
+	this.mu.Unlock()
+}
+
+func (this *API) strict() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.strictJSON
+}
+
+// Decodes response body into v. In strict mode body is decoded once more
+// into a fresh value, so errors of lenient decoding stay the same.
+func (this *API) decode(body []byte, v interface{}) error {
+	if err := ffjson.NewDecoder().Decode(body, v); err != nil {
+		return err
+	}
+	if !this.strict() {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.DisallowUnknownFields()
+	if err := d.Decode(reflect.New(reflect.TypeOf(v).Elem()).Interface()); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnexpectedField, err)
+	}
+	return nil
+}
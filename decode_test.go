@@ -0,0 +1,50 @@
+package hotellook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStrictJSON(t *testing.T) {
+	body := `{"searchId": 4034914, "status": "ok"}`
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(body), nil
+	})
+	req := &SearchRequest{CityID: 12153, CheckIn: "2016-12-10", CheckOut: "2016-12-17", AdultsCount: 2}
+
+	api.SetStrictJSON(true)
+	if _, err := api.StartSearch(req); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	body = `{"searchId": 4034914, "status": "ok", "expiresAt": 1481371200}`
+	if _, err := api.StartSearch(req); !errors.Is(err, ErrUnexpectedField) {
+		t.Fatalf("got %v, expected ErrUnexpectedField in strict mode", err)
+	}
+	api.SetStrictJSON(false)
+	resp, err := api.StartSearch(req)
+	if err != nil || resp.SearchID != 4034914 {
+		t.Fatalf("lenient mode returns %+v, %v", resp, err)
+	}
+}
+
+func TestStrictJSONStatic(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[{"id":"1","code":"RU","flag":"ru.png"}]`), nil
+	})
+	api.SetStrictJSON(true)
+	if _, err := api.Countries(); !errors.Is(err, ErrUnexpectedField) {
+		t.Fatalf("got %v, expected ErrUnexpectedField instead of ErrNoAccess", err)
+	}
+}
+
+func TestStrictJSONHotelList(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`{"gen_timestamp":1,"hotels":[],"region":"EU"}`), nil
+	})
+	api.SetStrictJSON(true)
+	if _, err := api.FetchHotelList("12153"); !errors.Is(err, ErrUnexpectedField) {
+		t.Fatalf("got %v, expected ErrUnexpectedField instead of ErrNoAccess", err)
+	}
+}
@@ -0,0 +1,102 @@
+package hotellook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pquerna/ffjson/ffjson"
+)
+
+// Error reported by API, either with non-2xx status or with status "error"
+// in response body.
+type APIError struct {
+	// HTTP status code of response.
+	StatusCode int
+	// Error code from response body, zero if server didn't send it.
+	Code    int
+	Message string
+	// URL of request with signature and token redacted, to reproduce it.
+	URL string
+}
+
+func (this *APIError) Error() string {
+	msg := this.Message
+	if msg == "" {
+		msg = http.StatusText(this.StatusCode)
+	}
+	if this.URL != "" {
+		msg += ", GET " + this.URL
+	}
+	if this.Code != 0 {
+		return fmt.Sprintf("HotelLook API error %d (HTTP %d): %s", this.Code, this.StatusCode, msg)
+	}
+	return fmt.Sprintf("HotelLook API error (HTTP %d): %s", this.StatusCode, msg)
+}
+
+// Query params which are never shown in errors.
+var secretParams = []string{"signature", "token"}
+
+// Returns URL with values of secret params replaced, so it can be logged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	q := u.Query()
+	for _, p := range secretParams {
+		if q.Get(p) != "" {
+			q.Set(p, "REDACTED")
+		}
+	}
+	u.RawQuery = encodeQuery(&q)
+	return u.String()
+}
+
+// Rejected credentials match ErrNoAccess, so errors.Is(err, ErrNoAccess)
+// works the same for missing token and for 401/403 responses.
+func (this *APIError) Is(target error) bool {
+	return target == ErrNoAccess &&
+		(this.StatusCode == http.StatusUnauthorized || this.StatusCode == http.StatusForbidden)
+}
+
+// Adds name of the endpoint to error of request or decoding, the cause
+// is still matched by errors.Is and errors.As.
+func wrapErr(endpoint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("hotellook: %s: %w", endpoint, err)
+}
+
+// Shape of error responses.
+type apiErrorBody struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	Error     string `json:"error"`
+	ErrorCode int    `json:"errorCode"`
+}
+
+// Returns *APIError if response has non-2xx status or body is an object
+// with status other than "ok".
+func checkResponse(r *http.Response, body []byte) error {
+	failed := r.StatusCode < 200 || r.StatusCode > 299
+	isObject := bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+	if !failed && !isObject {
+		return nil
+	}
+
+	var e apiErrorBody
+	if isObject {
+		ffjson.NewDecoder().Decode(body, &e)
+	}
+	if !failed && (e.Status == "" || e.Status == "ok") {
+		return nil
+	}
+	msg := e.Message
+	if msg == "" {
+		msg = e.Error
+	}
+	return &APIError{StatusCode: r.StatusCode, Code: e.ErrorCode, Message: msg}
+}
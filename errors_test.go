@@ -0,0 +1,156 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	status := http.StatusBadRequest
+	body := `{"status":"error","errorCode":4,"message":"Search is not finished."}`
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		resp := mockResponse(body)
+		resp.StatusCode = status
+		return resp, nil
+	})
+
+	_, err := api.Lookup(&LookupRequest{Query: "moscow"})
+	var e *APIError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, expected *APIError", err)
+	}
+	if e.StatusCode != 400 || e.Code != 4 || e.Message != "Search is not finished." {
+		t.Fatalf("unexpected error fields: %+v", e)
+	}
+	if !strings.Contains(e.Error(), "Search is not finished.") {
+		t.Fatal("error message is not surfaced: " + e.Error())
+	}
+
+	// Error status with 200 response.
+	status = http.StatusOK
+	if _, err = api.FetchSearchResults(&SearchResultsRequest{SearchID: 1}); err == nil {
+		t.Fatal("status \"error\" in body should be an error")
+	}
+
+	// Not a JSON at all.
+	status, body = http.StatusBadGateway, "<html>Bad Gateway</html>"
+	_, err = api.Lookup(&LookupRequest{Query: "moscow"})
+	if !errors.As(err, &e) || e.StatusCode != 502 || !strings.Contains(e.Error(), "Bad Gateway") {
+		t.Fatalf("got %v, expected *APIError with status 502", err)
+	}
+
+	status, body = http.StatusOK, `{"status":"ok","results":{}}`
+	if _, err = api.Lookup(&LookupRequest{Query: "moscow"}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestLookupPriceStatus(t *testing.T) {
+	status := http.StatusBadRequest
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		resp := mockResponse(`{"status":"error","message":"Wrong params"}`)
+		resp.StatusCode = status
+		return resp, nil
+	})
+	price := &PriceRequest{Location: "MOW", CheckIn: "2016-12-10", CheckOut: "2016-12-17"}
+
+	for _, status = range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		_, lookupErr := api.Lookup(&LookupRequest{Query: "moscow"})
+		prices, priceErr := api.Price(price)
+		if prices != nil {
+			t.Fatalf("Price returned %v with HTTP %d", prices, status)
+		}
+		for _, err := range []error{lookupErr, priceErr} {
+			var e *APIError
+			if !errors.As(err, &e) || e.StatusCode != status || e.Message != "Wrong params" {
+				t.Fatalf("got %v, expected *APIError with HTTP %d", err, status)
+			}
+			if errors.Is(err, ErrNoAccess) != (status == http.StatusUnauthorized) {
+				t.Fatalf("HTTP %d error matches ErrNoAccess: %v", status, errors.Is(err, ErrNoAccess))
+			}
+		}
+	}
+}
+
+func TestErrorURLRedacted(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("locationId") == "1" {
+			return nil, errors.New("connection refused")
+		}
+		resp := mockResponse(`{"status":"error","message":"Wrong params"}`)
+		resp.StatusCode = http.StatusBadRequest
+		return resp, nil
+	})
+	query := api.withSignature(map[string]string{"locationId": "12153"})
+	signature, _ := url.ParseQuery(query)
+
+	_, err := api.FetchHotelList("12153")
+	var e *APIError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, expected *APIError", err)
+	}
+	if !strings.Contains(e.URL, "locationId=12153") || !strings.Contains(e.URL, "signature=REDACTED") {
+		t.Fatalf("error URL %q should have params with redacted signature", e.URL)
+	}
+	if strings.Contains(err.Error(), signature.Get("signature")) || !strings.Contains(err.Error(), e.URL) {
+		t.Fatalf("error %q should have redacted URL", err.Error())
+	}
+
+	_, err = api.FetchHotelList("1")
+	if err == nil || !strings.Contains(err.Error(), "signature=REDACTED") || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("network error %v should have redacted URL", err)
+	}
+
+	if u := redactURL("http://engine.hotellook.com/api/v2/cache.json?token=secret&location=MOW"); u != "http://engine.hotellook.com/api/v2/cache.json?location=MOW&token=REDACTED" {
+		t.Fatalf("token is not redacted in %s", u)
+	}
+}
+
+func TestRedactedURLLogged(t *testing.T) {
+	var signature string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		signature = r.URL.Query().Get("signature")
+		return nil, errors.New("connection refused")
+	})
+	var logs []string
+	api.SetLogger(func(ctx context.Context, format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	})
+
+	api.FetchHotelList("12153")
+	if len(logs) != 1 || signature == "" {
+		t.Fatalf("got logs %q, expected failed request to be logged", logs)
+	}
+	if strings.Contains(logs[0], signature) || !strings.Contains(logs[0], "signature=REDACTED") {
+		t.Fatalf("log %q should have redacted URL", logs[0])
+	}
+}
+
+func TestWrappedErrors(t *testing.T) {
+	cause := errors.New("connection reset by peer")
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/api/v2/cache.json" {
+			return mockResponse(`{"hotelId": 1}`), nil
+		}
+		return nil, cause
+	})
+
+	_, err := api.Lookup(&LookupRequest{Query: "moscow"})
+	if !errors.Is(err, cause) || !strings.HasPrefix(err.Error(), "hotellook: lookup: ") {
+		t.Fatalf("got %v, expected network error wrapped with endpoint name", err)
+	}
+	_, err = api.Price(&PriceRequest{Location: "MOW"})
+	if err == nil || !strings.HasPrefix(err.Error(), "hotellook: price: ") || errors.Unwrap(err) == nil {
+		t.Fatalf("got %v, expected decoding error wrapped with endpoint name", err)
+	}
+
+	api.SetToken("")
+	if _, err = api.Countries(); err != ErrNoAccess {
+		t.Fatalf("got %v, expected ErrNoAccess as is", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/awskii/hotellook"
 	"log"
+	"os"
 )
 
 const (
@@ -49,11 +50,19 @@ func main() {
 	//     log.Fatalln(err.Error())
 	// }
 
-	resp, err := hl.FetchSearchResults(&hotellook.SearchResultsRequest{
-		SearchID: -1,
-		SortBy:   "price",
-		SortAsc:  1,
-	})
+	// resp, err := hl.FetchSearchResults(&hotellook.SearchResultsRequest{
+	//     SearchID: searchID,
+	//     SortBy:   "price",
+	//     SortAsc:  1,
+	// })
+
+	// Saved response is used instead.
+	f, err := os.Open("../test_data.json")
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	defer f.Close()
+	resp, err := hotellook.DecodeSearchResults(f)
 	if err != nil {
 		log.Fatalln(err.Error())
 	}
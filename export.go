@@ -0,0 +1,61 @@
+package hotellook
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type string `json:"type"`
+		// Longitude goes first.
+		Coordinates [2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		ID        int    `json:"id"`
+		Name      string `json:"name"`
+		Stars     int    `json:"stars"`
+		PriceFrom int    `json:"priceFrom"`
+	} `json:"properties"`
+}
+
+// Writes hotels as GeoJSON FeatureCollection of points.
+func (this *HotelList) WriteGeoJSON(w io.Writer) error {
+	collection := struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}{Type: "FeatureCollection", Features: make([]geoJSONFeature, len(this.Hotels))}
+
+	for i, h := range this.Hotels {
+		f := &collection.Features[i]
+		f.Type = "Feature"
+		f.Geometry.Type = "Point"
+		f.Geometry.Coordinates = [2]float64{h.Location.Logitude, h.Location.Latitude}
+		f.Properties.ID = h.ID
+		f.Properties.Name = h.Name.EN
+		f.Properties.Stars = h.Stars
+		f.Properties.PriceFrom = h.PriceFrom
+	}
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// Writes hotels as CSV with header row: id, name, lat, lon, stars, pricefrom.
+func (this *HotelList) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "lat", "lon", "stars", "pricefrom"})
+	for _, h := range this.Hotels {
+		cw.Write([]string{
+			strconv.Itoa(h.ID),
+			h.Name.EN,
+			strconv.FormatFloat(h.Location.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(h.Location.Logitude, 'f', -1, 64),
+			strconv.Itoa(h.Stars),
+			strconv.Itoa(h.PriceFrom),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,77 @@
+package hotellook
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+func exportList() *HotelList {
+	list := &HotelList{Hotels: make([]Hotel, 2)}
+	list.Hotels[0].ID = 1
+	list.Hotels[0].Name.EN = "Parus, Hotel"
+	list.Hotels[0].Stars = 4
+	list.Hotels[0].PriceFrom = 120
+	list.Hotels[0].Location = HotelLocation{Latitude: 55.75, Logitude: 37.61}
+	list.Hotels[1].ID = 2
+	list.Hotels[1].Name.EN = "Hostel"
+	return list
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportList().WriteGeoJSON(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	var fc struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatal(err.Error())
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("got %s with %d features, expected FeatureCollection of 2", fc.Type, len(fc.Features))
+	}
+	f := fc.Features[0]
+	if f.Type != "Feature" || f.Geometry.Type != "Point" {
+		t.Fatalf("got %s of %s, expected Feature of Point", f.Type, f.Geometry.Type)
+	}
+	if len(f.Geometry.Coordinates) != 2 || f.Geometry.Coordinates[0] != 37.61 || f.Geometry.Coordinates[1] != 55.75 {
+		t.Fatalf("got coordinates %v, expected [lon lat]", f.Geometry.Coordinates)
+	}
+	if f.Properties["name"] != "Parus, Hotel" || f.Properties["stars"] != 4.0 || f.Properties["priceFrom"] != 120.0 {
+		t.Fatalf("got properties %v", f.Properties)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportList().WriteCSV(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, expected header and 2 hotels", len(rows))
+	}
+	if rows[0][0] != "id" || rows[0][2] != "lat" {
+		t.Fatalf("got header %v", rows[0])
+	}
+	expected := []string{"1", "Parus, Hotel", "55.75", "37.61", "4", "120"}
+	for i, v := range expected {
+		if rows[1][i] != v {
+			t.Fatalf("got row %v, expected %v", rows[1], expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hotellook
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// Mean Earth radius in kilometers.
+const earthRadius = 6371.0
+
+// Great-circle distance between two points in kilometers (haversine formula).
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Distance to the point in kilometers.
+func (this Coordinates) DistanceTo(lat, lon float64) float64 {
+	return haversine(this.Lat, this.Lon, lat, lon)
+}
+
+// Distance to the point in kilometers.
+func (this HotelLocation) DistanceTo(lat, lon float64) float64 {
+	return haversine(this.Latitude, this.Logitude, lat, lon)
+}
+
+// Returns hotels within radiusKm from the point, nearest first.
+func (this *HotelList) Near(lat, lon, radiusKm float64) []Hotel {
+	type near struct {
+		hotel    Hotel
+		distance float64
+	}
+	var found []near
+	for _, h := range this.Hotels {
+		d := h.Location.DistanceTo(lat, lon)
+		if d <= radiusKm {
+			found = append(found, near{h, d})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+
+	hotels := make([]Hotel, len(found))
+	for i := range found {
+		hotels[i] = found[i].hotel
+	}
+	return hotels
+}
+
+// API has no geo search, so it fetches whole hotel list of the location
+// and filters it by distance to the point. Hotels are sorted by distance.
+func (this *API) FetchHotelsNear(ctx context.Context, locationId string, lat, lon, radiusKm float64) ([]Hotel, error) {
+	list, err := this.fetchHotelList(ctx, locationId)
+	if err != nil {
+		return nil, err
+	}
+	return list.Near(lat, lon, radiusKm), nil
+}
@@ -0,0 +1,90 @@
+package hotellook
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	// Moscow - Saint-Petersburg, ~634 km.
+	d := haversine(55.7558, 37.6173, 59.9343, 30.3351)
+	if math.Abs(d-634) > 5 {
+		t.Fatalf("haversine returns %f, expected ~634", d)
+	}
+	if d := haversine(55.7558, 37.6173, 55.7558, 37.6173); d != 0 {
+		t.Fatalf("distance between same points should be 0, got %f", d)
+	}
+}
+
+func TestDistanceTo(t *testing.T) {
+	cases := []struct {
+		from     Coordinates
+		lat, lon float64
+		km       float64
+	}{
+		{Coordinates{51.5074, -0.1278}, 48.8566, 2.3522, 344},      // London - Paris
+		{Coordinates{40.7128, -74.0060}, 34.0522, -118.2437, 3936}, // New York - Los Angeles
+		{Coordinates{-33.8688, 151.2093}, -37.8136, 144.9631, 714}, // Sydney - Melbourne
+	}
+	for _, c := range cases {
+		if d := c.from.DistanceTo(c.lat, c.lon); math.Abs(d-c.km) > c.km*0.01 {
+			t.Fatalf("distance from %v to %f,%f is %f, expected ~%f", c.from, c.lat, c.lon, d, c.km)
+		}
+		loc := HotelLocation{Latitude: c.from.Lat, Logitude: c.from.Lon}
+		if loc.DistanceTo(c.lat, c.lon) != c.from.DistanceTo(c.lat, c.lon) {
+			t.Fatal("HotelLocation and Coordinates distances differ")
+		}
+	}
+}
+
+func TestHotelListNear(t *testing.T) {
+	list := &HotelList{Hotels: make([]Hotel, 4)}
+	coords := [][2]float64{
+		{59.9343, 30.3351}, // Saint-Petersburg center
+		{55.7558, 37.6173}, // Moscow, far away
+		{59.9500, 30.3167}, // ~2 km from the center
+		{59.9386, 30.3141}, // ~1.2 km from the center
+	}
+	for i, c := range coords {
+		list.Hotels[i].ID = i + 1
+		list.Hotels[i].Location.Latitude = c[0]
+		list.Hotels[i].Location.Logitude = c[1]
+	}
+
+	hotels := list.Near(59.9343, 30.3351, 5)
+	if len(hotels) != 3 {
+		t.Fatalf("got %d hotels, expected 3", len(hotels))
+	}
+	for i, id := range []int{1, 4, 3} {
+		if hotels[i].ID != id {
+			t.Fatalf("hotels are not sorted by distance: position %d has ID %d, expected %d", i, hotels[i].ID, id)
+		}
+	}
+	if len(list.Near(0, 0, 100)) != 0 {
+		t.Fatal("Near should return nothing when there are no hotels in radius")
+	}
+}
+
+func TestFetchHotelsNear(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`{"hotels": [
+			{"id": 1, "location": {"lat": 55.7558, "lon": 37.6173}},
+			{"id": 2, "location": {"lat": 59.9386, "lon": 30.3141}}
+		]}`), nil
+	})
+	hotels, err := api.FetchHotelsNear(context.Background(), "12153", 59.9343, 30.3351, 5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(hotels) != 1 || hotels[0].ID != 2 {
+		t.Fatalf("got hotels %+v, expected only hotel in Saint-Petersburg", hotels)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.FetchHotelsNear(ctx, "12153", 59.9343, 30.3351, 5); err == nil {
+		t.Fatal("hotel list was fetched with cancelled context")
+	}
+}
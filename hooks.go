@@ -0,0 +1,57 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Receives log messages of API. Context is the one of the request,
+// so correlation IDs can be taken from it.
+type Logger func(ctx context.Context, format string, args ...interface{})
+
+// Receives status and duration of every request to API, status is zero
+// if request failed. Context is the one of the request.
+type Metrics func(ctx context.Context, endpoint string, status int, duration time.Duration)
+
+// Receives headers of every response of API, e.g. to keep request IDs
+// for support. Header should not be modified.
+type ResponseHook func(ctx context.Context, endpoint string, header http.Header)
+
+// Sets logger of requests, nil disables logging.
+func (this *API) SetLogger(l Logger) {
+	this.mu.Lock()
+	this.logger = l
+	this.mu.Unlock()
+}
+
+// Sets metrics hook, nil disables it.
+func (this *API) SetMetrics(m Metrics) {
+	this.mu.Lock()
+	this.metrics = m
+	this.mu.Unlock()
+}
+
+// Sets response hook, nil disables it.
+func (this *API) SetResponseHook(h ResponseHook) {
+	this.mu.Lock()
+	this.onResponse = h
+	this.mu.Unlock()
+}
+
+// Reports finished request to the hooks.
+func (this *API) observe(ctx context.Context, endpoint string, status int, duration time.Duration, err error) {
+	this.mu.Lock()
+	logger, metrics := this.logger, this.metrics
+	this.mu.Unlock()
+	if logger != nil {
+		if err != nil {
+			logger(ctx, "hotellook: %s failed after %v: %v", endpoint, duration, err)
+		} else {
+			logger(ctx, "hotellook: %s responded %d in %v", endpoint, status, duration)
+		}
+	}
+	if metrics != nil {
+		metrics(ctx, endpoint, status, duration)
+	}
+}
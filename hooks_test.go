@@ -0,0 +1,75 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func TestHooksContext(t *testing.T) {
+	fail := false
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return mockResponse(`{"results": {}}`), nil
+	})
+	var logs, traces []string
+	var statuses []int
+	api.SetLogger(func(ctx context.Context, format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+		traces = append(traces, fmt.Sprint(ctx.Value(traceKey{})))
+	})
+	api.SetMetrics(func(ctx context.Context, endpoint string, status int, d time.Duration) {
+		if endpoint != EndpointLookup {
+			t.Fatalf("got metrics of %s, expected lookup", endpoint)
+		}
+		statuses = append(statuses, status)
+		traces = append(traces, fmt.Sprint(ctx.Value(traceKey{})))
+	})
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	if _, err := api.lookup(ctx, &LookupRequest{Query: "moscow"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	fail = true
+	api.lookup(ctx, &LookupRequest{Query: "moscow"})
+
+	if len(logs) != 2 || len(statuses) != 2 || statuses[0] != 200 || statuses[1] != 0 {
+		t.Fatalf("got logs %q and statuses %v", logs, statuses)
+	}
+	for _, trace := range traces {
+		if trace != "trace-1" {
+			t.Fatalf("hook got trace %q, expected value from request context", trace)
+		}
+	}
+}
+
+func TestResponseHook(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		resp := mockResponse(`[]`)
+		resp.Header.Set("X-Request-Id", "8f2b6c1e")
+		return resp, nil
+	})
+	var ids []string
+	api.SetResponseHook(func(ctx context.Context, endpoint string, header http.Header) {
+		ids = append(ids, endpoint+" "+header.Get("X-Request-Id"))
+	})
+	if _, err := api.Countries(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ids) != 1 || ids[0] != "countries 8f2b6c1e" {
+		t.Fatalf("hook got %q, expected request ID of countries", ids)
+	}
+
+	api.SetResponseHook(nil)
+	api.Countries()
+	if len(ids) != 1 {
+		t.Fatal("hook is called after it was removed")
+	}
+}
@@ -5,10 +5,14 @@
 package hotellook
 
 import (
+	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -17,16 +21,54 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pquerna/ffjson/ffjson"
 )
 
 const apiURL = "http://engine.hotellook.com/api/v2/"
 
+// Names of API endpoints, see also names of static datasets (StaticCountries...).
+const (
+	EndpointLookup        = "lookup"
+	EndpointPrice         = "price"
+	EndpointSearch        = "search"
+	EndpointSearchResults = "searchResults"
+	EndpointHotels        = "hotels"
+)
+
+// Paths of endpoints relative to apiURL.
+var endpointPaths = map[string]string{
+	EndpointLookup:        "lookup.json?",
+	EndpointPrice:         "cache.json?",
+	EndpointSearch:        "search/start.json?",
+	EndpointSearchResults: "search/getResult.json?",
+	EndpointHotels:        "static/hotels.json?",
+	StaticCountries:       "static/countries.json?",
+	StaticCities:          "static/locations.json?",
+	StaticAmenities:       "static/amenities.json?",
+	StaticRoomTypes:       "static/roomTypes.json?",
+}
+
 var (
 	ErrNoAccess      = errors.New("You should specify valid token and marker to use this method")
 	ErrEmptySearchID = errors.New("Empty search ID")
 	ErrMissingParams = errors.New("Missing required parameters")
+	// Several parameters select the same thing (e.g. HotelID and Hotel).
+	ErrConflictingParams = errors.New("Conflicting parameters")
+	// Response body is shorter than announced Content-Length.
+	ErrTruncatedResponse = errors.New("Truncated response body")
+	ErrInvalidJSON       = errors.New("Response is not a valid JSON")
+	ErrResponseTooLarge  = errors.New("Response body exceeds size limit")
+	ErrNotFound          = errors.New("Not found")
+	ErrUnknownEndpoint   = errors.New("Unknown endpoint name")
+	// API redirected request to another host or from https to http.
+	ErrRedirect         = errors.New("Redirect is not allowed")
+	ErrCurrencyMismatch = errors.New("Amounts in different currencies")
+	// Response has fields unknown to this package, see SetStrictJSON.
+	ErrUnexpectedField = errors.New("Unexpected field in response")
+	// Request is not made after failures of endpoint, see SetCircuitBreaker.
+	ErrCircuitOpen = errors.New("Circuit breaker is open")
 )
 
 type API struct {
@@ -35,8 +77,51 @@ type API struct {
 
 	mu      sync.Mutex
 	remains int
-	limit   int
-	client  *http.Client
+	// Requests held back by ReserveQuota.
+	reserved int
+	limit    int
+	reset    time.Time
+	total    int
+	errors   int
+	maxBody  int64
+	client   *http.Client
+	header   http.Header
+	clock    clock
+	// Static datasets by name, nil if caching is disabled.
+	static map[string]*staticData
+	// In-flight requests by URL, nil if coalescing is disabled.
+	flights map[string]*flight
+	// Countries by code, built on first CountryByCode call.
+	countries map[string]*Countries
+	// Built on first AmenityFilterTree call.
+	amenityGroups []AmenityGroup
+	// Built on first CityIDToIATA or IATAToCityID call.
+	cityCodes *cityCodes
+	// Hotel lists by location ID, fetched by EnrichWithStatic.
+	hotelLists map[string]*cachedHotelList
+	// Endpoints which are requested again after an empty response.
+	retryEmpty map[string]bool
+	// Occupancy of price requests which don't specify it.
+	adults, children, infants int
+	// Endpoint paths set by SetEndpoint.
+	paths      map[string]string
+	logger     Logger
+	metrics    Metrics
+	onResponse ResponseHook
+	// Host of links in search results, set by SetAffiliateHost.
+	affiliateHost string
+	// Hash of signature, MD5 if nil.
+	newHash         func() hash.Hash
+	debugSignatures bool
+	strictJSON      bool
+	// Concurrency limit set by SetMaxConcurrency, nil if requests are not limited.
+	slots chan struct{}
+	// Circuit breaker config set by SetCircuitBreaker and states by endpoint.
+	breakerThreshold int
+	breakerCoolDown  time.Duration
+	breakers         map[string]*breakerState
+	// Endpoint timeouts set by SetEndpointTimeout.
+	timeouts map[string]time.Duration
 }
 
 func NewAPI(marker int) *API {
@@ -45,69 +130,511 @@ func NewAPI(marker int) *API {
 	}
 	return &API{
 		marker: marker,
+		client: &http.Client{Transport: DefaultTransport(), CheckRedirect: checkRedirect},
+	}
+}
+
+// Returns API with the same configuration, which can be changed without
+// affecting this one. HTTP transport, concurrency limit and cached static
+// datasets are shared, rate limit state and statistics are not copied.
+func (this *API) Clone() *API {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	c := &API{
+		token:            this.token,
+		marker:           this.marker,
+		maxBody:          this.maxBody,
+		header:           this.header.Clone(),
+		clock:            this.clock,
+		retryEmpty:       copyBoolMap(this.retryEmpty),
+		adults:           this.adults,
+		children:         this.children,
+		infants:          this.infants,
+		logger:           this.logger,
+		metrics:          this.metrics,
+		onResponse:       this.onResponse,
+		affiliateHost:    this.affiliateHost,
+		newHash:          this.newHash,
+		debugSignatures:  this.debugSignatures,
+		strictJSON:       this.strictJSON,
+		slots:            this.slots,
+		breakerThreshold: this.breakerThreshold,
+		breakerCoolDown:  this.breakerCoolDown,
+	}
+	if this.client != nil {
+		client := *this.client
+		c.client = &client
+	}
+	if this.static != nil {
+		c.static = make(map[string]*staticData, len(this.static))
+		for name, data := range this.static {
+			c.static[name] = data
+		}
+	}
+	if this.flights != nil {
+		c.flights = make(map[string]*flight)
+	}
+	if this.paths != nil {
+		c.paths = make(map[string]string, len(this.paths))
+		for name, path := range this.paths {
+			c.paths[name] = path
+		}
+	}
+	if this.timeouts != nil {
+		c.timeouts = make(map[string]time.Duration, len(this.timeouts))
+		for name, d := range this.timeouts {
+			c.timeouts[name] = d
+		}
+	}
+	return c
+}
+
+func copyBoolMap(m map[string]bool) map[string]bool {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Token and marker can be changed while requests are in progress.
+func (this *API) SetToken(token string) {
+	this.mu.Lock()
+	this.token = token
+	this.mu.Unlock()
+}
+
+// Sets partner marker, zero marker is ignored.
+func (this *API) SetMarker(marker int) {
+	if marker == 0 {
+		return
+	}
+	this.mu.Lock()
+	this.marker = marker
+	this.mu.Unlock()
+}
+
+func (this *API) credentials() (string, int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.token, this.marker
+}
+
+// Sets header which will be sent with every request to API.
+func (this *API) SetHeader(key, value string) {
+	this.mu.Lock()
+	if this.header == nil {
+		this.header = make(http.Header)
+	}
+	this.header.Set(key, value)
+	this.mu.Unlock()
+}
+
+// Limits size of response body, larger responses cause ErrResponseTooLarge.
+// Static datasets (Countries, Cities and so on) are not limited. Zero means no limit.
+func (this *API) SetMaxResponseBytes(n int64) {
+	this.mu.Lock()
+	this.maxBody = n
+	this.mu.Unlock()
+}
+
+// Makes requests to given endpoints (EndpointPrice, EndpointLookup...) be
+// repeated once if API responded with an empty body, which happens under load.
+func (this *API) RetryOnEmptyBody(endpoints ...string) {
+	this.mu.Lock()
+	if this.retryEmpty == nil {
+		this.retryEmpty = make(map[string]bool)
+	}
+	for _, e := range endpoints {
+		this.retryEmpty[e] = true
+	}
+	this.mu.Unlock()
+}
+
+// Overrides path of the endpoint with given name (EndpointLookup, StaticCities...).
+// Path is relative to API URL (e.g. "v3/lookup.json"), or an absolute URL.
+func (this *API) SetEndpoint(name, path string) error {
+	if _, ok := endpointPaths[name]; !ok {
+		return ErrUnknownEndpoint
+	}
+	if !strings.HasSuffix(path, "?") {
+		path += "?"
+	}
+	this.mu.Lock()
+	if this.paths == nil {
+		this.paths = make(map[string]string)
+	}
+	this.paths[name] = path
+	this.mu.Unlock()
+	return nil
+}
+
+// Limits duration of requests to the endpoint, including reading of response
+// body (e.g. longer one for StaticCities than for EndpointLookup). Zero
+// duration removes the limit, client timeout is applied anyway.
+func (this *API) SetEndpointTimeout(name string, d time.Duration) error {
+	if _, ok := endpointPaths[name]; !ok {
+		return ErrUnknownEndpoint
 	}
+	this.mu.Lock()
+	if this.timeouts == nil {
+		this.timeouts = make(map[string]time.Duration)
+	}
+	if d > 0 {
+		this.timeouts[name] = d
+	} else {
+		delete(this.timeouts, name)
+	}
+	this.mu.Unlock()
+	return nil
+}
+
+// Returns URL of the endpoint, to which query is appended.
+func (this *API) endpointURL(name string) string {
+	this.mu.Lock()
+	path, ok := this.paths[name]
+	this.mu.Unlock()
+	if !ok {
+		path = endpointPaths[name]
+	}
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return apiURL + path
+}
+
+// Sets host of hotel and booking links in search results, e.g. localized
+// domain "search.hotellook.ru". Empty host keeps links as API returns them.
+func (this *API) SetAffiliateHost(host string) {
+	this.mu.Lock()
+	this.affiliateHost = host
+	this.mu.Unlock()
+}
+
+// Replaces hash function of request signature. HotelLook checks MD5
+// signatures, so it's useful only for testing, nil restores MD5.
+func (this *API) SetSignatureHash(fn func() hash.Hash) {
+	this.mu.Lock()
+	this.newHash = fn
+	this.mu.Unlock()
 }
 
-func (this *API) SetToken(token string) { this.token = token }
+// Sets number of guests used by Price when request leaves them zero.
+// Without it adults default to 2, children and infants to none.
+func (this *API) SetDefaultOccupancy(adults, children, infants int) {
+	this.mu.Lock()
+	this.adults, this.children, this.infants = adults, children, infants
+	this.mu.Unlock()
+}
 
 // Return number of remaining requests to HotelLook API. (X-Ratelimit-Remaining )
-func (this *API) RequestsRemains() int { return 0 }
+func (this *API) RequestsRemains() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.remains
+}
 
 // Returns numeric value of API rate limit. (X-Ratelimit-Limit )
-func (this *API) RequestsLimit() int { return 0 }
+func (this *API) RequestsLimit() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.limit
+}
 
+// Headers may be absent (e.g. on errors or cached responses), in that case
+// previously known values are kept.
 func (this *API) updateRemains(r *http.Response) {
 	this.mu.Lock()
-	this.remains, _ = strconv.Atoi(r.Header.Get("X-Ratelimit-Remaining"))
-	this.limit, _ = strconv.Atoi(r.Header.Get("X-Ratelimit-Limit"))
+	if n, err := strconv.Atoi(r.Header.Get("X-Ratelimit-Remaining")); err == nil {
+		this.remains = n
+	}
+	if n, err := strconv.Atoi(r.Header.Get("X-Ratelimit-Limit")); err == nil {
+		this.limit = n
+	}
+	if n, err := strconv.ParseInt(r.Header.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+		this.reset = resetTime(this.now(), n)
+	}
+	this.mu.Unlock()
+}
+
+func (this *API) httpClient() *http.Client {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.client != nil {
+		return this.client
+	}
+	return http.DefaultClient
+}
+
+// Performs GET request to the endpoint with given name. Non-empty lang is sent
+// as Accept-Language, unless it was set by SetHeader. Caller must close body.
+func (this *API) do(ctx context.Context, endpoint, query, lang string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", this.endpointURL(endpoint)+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	release, err := this.acquire(ctx)
+	if err != nil {
+		return nil, wrapErr(endpoint, err)
+	}
+	if err = this.breakerAllow(endpoint); err != nil {
+		if release != nil {
+			release()
+		}
+		return nil, wrapErr(endpoint, err)
+	}
+	this.mu.Lock()
+	for k, v := range this.header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	for k, v := range requestHeader(ctx) {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	debug := this.debugSignatures
+	timeout := this.timeouts[endpoint]
+	this.mu.Unlock()
+	callerCtx := ctx
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	finish := func() {
+		if cancel != nil {
+			cancel()
+		}
+		if release != nil {
+			release()
+		}
+	}
+	req = req.WithContext(ctx)
+	if lang != "" && req.Header.Get("Accept-Language") == "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	if debug {
+		this.checkSignature(ctx, endpoint, query)
+	}
+
+	start := this.now()
+	r, err := this.httpClient().Do(req)
+	// Endpoint timeout is a failure of API, cancellation by caller is not.
+	if callerCtx.Err() != nil {
+		this.breakerRelease(endpoint)
+	} else {
+		this.breakerDone(endpoint, r, err)
+	}
+	if err == nil && ctx.Err() != nil {
+		// Caller gave up while response was coming, so it's dropped and nothing
+		// is done on behalf of the request. It was spent anyway, so rate limit
+		// headers are kept.
+		this.updateRemains(r)
+		this.spendReservation(ctx)
+		r.Body.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		finish()
+		// Client error includes URL of request, which has signature.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(urlErr.URL)
+		}
+		this.observe(ctx, endpoint, 0, this.now().Sub(start), err)
+		this.countRequest(err)
+		return nil, wrapErr(endpoint, err)
+	}
+	if cancel != nil || release != nil {
+		r.Body = &cancelBody{r.Body, finish}
+	}
+	this.observe(ctx, endpoint, r.StatusCode, this.now().Sub(start), nil)
+	this.updateRemains(r)
+	this.spendReservation(ctx)
+	this.mu.Lock()
+	onResponse := this.onResponse
+	this.mu.Unlock()
+	if onResponse != nil {
+		onResponse(ctx, endpoint, r.Header)
+	}
+	return r, nil
+}
+
+// Releases timeout context and concurrency slot of request when body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (this *cancelBody) Close() error {
+	err := this.ReadCloser.Close()
+	this.cancel()
+	return err
+}
+
+// Same as do, but returns whole response body. Identical concurrent
+// requests share one round trip if coalescing is enabled.
+func (this *API) get(ctx context.Context, endpoint, query, lang string) ([]byte, error) {
+	body, _, err := this.getWithHeader(ctx, endpoint, query, lang)
+	return body, err
+}
+
+// Same as get, but also returns header of the response.
+func (this *API) getWithHeader(ctx context.Context, endpoint, query, lang string) ([]byte, http.Header, error) {
+	this.mu.Lock()
+	coalesce := this.flights != nil
+	this.mu.Unlock()
+	if coalesce {
+		key := lang + " " + endpoint + query + headerKey(requestHeader(ctx))
+		return this.coalesce(ctx, key, func() ([]byte, http.Header, error) {
+			return this.getBody(ctx, endpoint, query, lang)
+		})
+	}
+	return this.getBody(ctx, endpoint, query, lang)
+}
+
+func (this *API) getBody(ctx context.Context, endpoint, query, lang string) ([]byte, http.Header, error) {
+	body, header, err := this.getOnce(ctx, endpoint, query, lang)
+	if err != nil || len(bytes.TrimSpace(body)) > 0 {
+		return body, header, err
+	}
+	this.mu.Lock()
+	retry := this.retryEmpty[endpoint]
 	this.mu.Unlock()
+	if !retry {
+		return body, header, nil
+	}
+	return this.getOnce(ctx, endpoint, query, lang)
+}
+
+func (this *API) getOnce(ctx context.Context, endpoint, query, lang string) ([]byte, http.Header, error) {
+	r, err := this.do(ctx, endpoint, query, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+	var max int64
+	// Static datasets are known to be large.
+	if endpoint != EndpointHotels && !isStatic(endpoint) {
+		this.mu.Lock()
+		max = this.maxBody
+		this.mu.Unlock()
+	}
+	body, err := readBody(r, max)
+	this.countRequest(err)
+	if err != nil {
+		return nil, nil, wrapErr(endpoint, err)
+	}
+	if err = checkResponse(r, body); err != nil {
+		var e *APIError
+		if errors.As(err, &e) {
+			e.URL = redactURL(this.endpointURL(endpoint) + query)
+		}
+		return nil, nil, wrapErr(endpoint, err)
+	}
+	return body, r.Header, nil
+}
+
+// Reads and closes response body. Partial body of a large array can be decoded
+// without errors, so the length is checked against Content-Length when it's known.
+// Body longer than max bytes is not read entirely, zero max means no limit.
+func readBody(r *http.Response, max int64) ([]byte, error) {
+	var src io.Reader = r.Body
+	if max > 0 {
+		src = io.LimitReader(r.Body, max+1)
+	}
+	body, err := ioutil.ReadAll(src)
+	r.Body.Close()
+	if err == io.ErrUnexpectedEOF {
+		return nil, ErrTruncatedResponse
+	}
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 && int64(len(body)) > max {
+		return nil, ErrResponseTooLarge
+	}
+	if r.ContentLength > 0 && int64(len(body)) != r.ContentLength {
+		return nil, ErrTruncatedResponse
+	}
+	return body, nil
 }
 
 // Returns urlencoded params with calculated signature.
 func (this *API) withSignature(params map[string]string) string {
 	var keys sort.StringSlice
-	src := this.token + ":" + strconv.Itoa(this.marker)
-	hash := md5.New()
+	token, marker := this.credentials()
+	this.mu.Lock()
+	newHash := this.newHash
+	this.mu.Unlock()
+	if newHash == nil {
+		newHash = md5.New
+	}
+	hash := newHash()
 	v := &url.Values{}
 
-	if params != nil {
-		for k, _ := range params {
-			keys = append(keys, k)
-		}
-		keys.Sort()
-		for _, k := range keys {
-			src += ":" + params[k]
-			v.Add(k, params[k])
-		}
+	for k, _ := range params {
+		keys = append(keys, k)
 	}
-	hash.Write([]byte(src))
+	keys.Sort()
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, params[k])
+		v.Add(k, params[k])
+	}
+	hash.Write([]byte(signatureSource(token, marker, values)))
 
-	v.Add("marker", strconv.Itoa(this.marker))
+	v.Add("marker", strconv.Itoa(marker))
 	v.Add("signature", hex.EncodeToString(hash.Sum(nil)))
-	return v.Encode()
+	return encodeQuery(v)
+}
+
+// Builds string which is hashed into request signature:
+// token and marker followed by param values, joined with colons.
+// Values must be sorted by names of their params. Values are not escaped,
+// so "a:b" and "a", "b" yield the same source, as server expects.
+func signatureSource(token string, marker int, sortedParams []string) string {
+	parts := append([]string{token, strconv.Itoa(marker)}, sortedParams...)
+	return strings.Join(parts, ":")
+}
+
+// Works like url.Values.Encode, but escapes spaces as %20 instead of "+".
+// Signature is calculated over raw values, so server should get exactly
+// the same values after unescaping, and not every decoder treats "+" as space.
+func encodeQuery(v *url.Values) string {
+	return strings.Replace(v.Encode(), "+", "%20", -1)
 }
 
 // If you have no token, closed API methods will return ErrNoAccess.
 func (this *API) checkAccess() error {
-	if this.token == "" || this.marker == 0 {
+	if token, marker := this.credentials(); token == "" || marker == 0 {
 		return ErrNoAccess
 	}
 	return nil
 }
 
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
 type LookupRequest struct {
-	Query string
+	Query string `query:"query"`
 	// Any ISO language code (fr, de, ru...). Default is en.
-	Lang string
+	Lang string `query:"lang"`
 	// city/hotel/both
 	// City - cities and islands
 	// Hotel - only hotels
 	// Both - all values. Default.
-	LookFor string
+	LookFor string `query:"lookFor"`
 	// 10 by default.
-	Limit int
-	// Automatically change of keyboard map (actual for russian users). Default 1.
-	ConvertCase int
+	Limit int `query:"limit,omitempty"`
+	// Automatically change of keyboard map (actual for russian users).
+	// Zero sends nothing, and server enables it by default.
+	ConvertCase int `query:"convertCase,omitempty"`
+	// Sends convertCase=0 to disable keyboard map change, ConvertCase is ignored then.
+	DisableConvertCase bool `query:"-"`
+	// Cleans up Query with NormalizeQuery before sending.
+	Normalize bool `query:"-"`
 }
 
 type LookupResponse struct {
@@ -127,136 +654,173 @@ type LookupResponse struct {
 			} `json:"location"`
 			Score float64 `json:"_score,omitempty"`
 		} `json:"locations"`
-		Hotels []struct {
-			ID           interface{} `json:"id"`
-			FullName     string      `json:"fullName"`
-			LocationName string      `json:"locationName"`
-			Label        string      `json:"label"`
-			LocationID   int         `json:"locationId"`
-			Location     struct {
-				Lat float64 `json:"lat"`
-				Lon float64 `json:"lon"`
-			} `json:"location"`
-			Score float64 `json:"_score"`
-		} `json:"hotels"`
+		Hotels []LookupHotel `json:"hotels"`
 	} `json:"results"`
 }
 
+// Hotel found by Lookup.
+type LookupHotel struct {
+	ID           interface{} `json:"id"`
+	FullName     string      `json:"fullName"`
+	LocationName string      `json:"locationName"`
+	Label        string      `json:"label"`
+	LocationID   int         `json:"locationId"`
+	Location     Coordinates `json:"location"`
+	Score        float64     `json:"_score"`
+}
+
 // Watch https://support.travelpayouts.com/hc/ru/articles/203956133-API-поиска-отелей#31
-func (this *API) Lookup(req *LookupRequest) (*LookupResponse, error) {
-	const endpoint = "lookup.json?"
-	v := &url.Values{}
+func (this *API) Lookup(req *LookupRequest, opts ...RequestOption) (*LookupResponse, error) {
+	return this.lookup(withRequestOptions(context.Background(), opts), req)
+}
 
-	v.Add("query", req.Query)
-	v.Add("lang", req.Lang)
-	v.Add("lookFor", req.LookFor)
-	if req.Limit != 0 {
-		v.Add("limit", strconv.Itoa(req.Limit))
-	}
-	if req.ConvertCase != 0 {
-		v.Add("convertCase", strconv.Itoa(req.ConvertCase))
-	}
-	r, err := http.Get(apiURL + endpoint + v.Encode())
+func (this *API) lookup(ctx context.Context, req *LookupRequest) (*LookupResponse, error) {
+	body, err := this.lookupBody(ctx, req)
 	if err != nil {
 		return &LookupResponse{}, err
 	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 
 	resp := new(LookupResponse)
-	if err = ffjson.NewDecoder().Decode(body, resp); err != nil {
-		return &LookupResponse{}, err
+	if err = this.decode(body, resp); err != nil {
+		return &LookupResponse{}, wrapErr(EndpointLookup, err)
 	}
 
 	return resp, nil
 }
 
+func (this *API) lookupBody(ctx context.Context, req *LookupRequest) ([]byte, error) {
+	const endpoint = EndpointLookup
+	params := encodeParams(req)
+	if req.Normalize {
+		params["query"] = NormalizeQuery(req.Query)
+	}
+	if req.DisableConvertCase {
+		params["convertCase"] = "0"
+	}
+	v := paramValues(params)
+	return this.get(ctx, endpoint, encodeQuery(v), req.Lang)
+}
+
 type PriceRequest struct {
-	Location   string
-	CheckIn    string // 2016-12-10
-	CheckOut   string // 2016-12-10
-	Currency   string
-	LocationID int
-	HotelID    int
-	Hotel      string
-	Adults     int // Number of adults. By default, it equals 2.
-	Children   int // Childrens, age 2-18.
-	Infants    int // Infants, ag 0-2.
+	Location   string `query:"location"`
+	CheckIn    string `query:"checkIn"`  // 2016-12-10, local to the hotel, see DateLayout
+	CheckOut   string `query:"checkOut"` // 2016-12-10
+	Currency   string `query:"currency,omitempty"`
+	LocationID int    `query:"locationId,omitempty"`
+	HotelID    int    `query:"hotelId,omitempty"`
+	Hotel      string `query:"hotel,omitempty"`
+	Adults     int    // Number of adults. By default, it equals 2, see SetDefaultOccupancy.
+	Children   int    // Childrens, age 2-18.
+	Infants    int    // Infants, ag 0-2.
 	Limit      int
-	CustomerIP net.IP
+	CustomerIP net.IP `query:"clientIp,omitempty"`
+	// Return only hotels with that many stars (1-5), zero means any.
+	// API has no such param, so hotels are filtered after response
+	// and less than Limit of them may be returned.
+	Stars int
 }
 
+// Currency of prices when request doesn't specify it.
+const defaultCurrency = "USD"
+
+// Number of adults when neither request nor SetDefaultOccupancy specify it.
+const defaultAdults = 2
+
 type PriceResponse struct {
-	Stars      int     `json:"stars"`
-	HotelID    int     `json:"hotelId"`
-	HotelName  string  `json:"hotelName"`
-	PriceAvg   float64 `json:"priceAvg"`
-	PriceFrom  float64 `json:"priceFrom"`
-	LocationID int     `json:"locationId"`
+	Stars     int     `json:"stars"`
+	HotelID   int     `json:"hotelId"`
+	HotelName string  `json:"hotelName"`
+	PriceAvg  float64 `json:"priceAvg"`
+	PriceFrom float64 `json:"priceFrom"`
+	// Currency of prices, API doesn't return it, so it's filled from request.
+	Currency   string `json:"currency,omitempty"`
+	LocationID int    `json:"locationId"`
 	Location   struct {
-		Country string `json:"country"`
-		State   string `json:"state"`
-		Name    string `json:"name"`
-		Geo     struct {
-			Lon float64 `json:"lon"`
-			Lat float64 `json:"lat"`
-		} `json:"geo"`
+		Country string      `json:"country"`
+		State   string      `json:"state"`
+		Name    string      `json:"name"`
+		Geo     Coordinates `json:"geo"`
 	} `json:"location"`
 }
 
 // Watch https://support.travelpayouts.com/hc/ru/articles/203956133-API-поиска-отелей#34
-func (this *API) Price(req *PriceRequest) (*[]PriceResponse, error) {
-	const endpoint = "cache.json?"
-
-	v := &url.Values{}
+func (this *API) Price(req *PriceRequest, opts ...RequestOption) (*[]PriceResponse, error) {
+	return this.price(withRequestOptions(context.Background(), opts), req)
+}
 
-	v.Add("location", req.Location)
-	v.Add("checkIn", req.CheckIn)
-	v.Add("checkOut", req.CheckOut)
-	if req.LocationID != 0 {
-		v.Add("locationId", strconv.Itoa(req.LocationID))
-	}
-	if req.HotelID != 0 {
-		v.Add("hotleId", strconv.Itoa(req.HotelID))
-	}
-	if req.Hotel != "" {
-		v.Add("hotel", req.Hotel)
-	}
-	if req.Adults != 0 {
-		v.Add("adults", strconv.Itoa(req.Adults))
+func (this *API) price(ctx context.Context, req *PriceRequest) (*[]PriceResponse, error) {
+	body, err := this.priceBody(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-	if req.Children != 0 {
-		v.Add("children", strconv.Itoa(req.Children))
+	var resp []PriceResponse
+	if err = this.decode(body, &resp); err != nil {
+		return nil, wrapErr(EndpointPrice, err)
 	}
-	if req.Currency != "" {
-		v.Add("currency", req.Currency)
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = defaultCurrency
 	}
-	if req.Infants != 0 {
-		v.Add("infants", strconv.Itoa(req.Infants))
+	for i := range resp {
+		if resp[i].Currency == "" {
+			resp[i].Currency = currency
+		}
 	}
-	if req.Limit != 0 {
-		v.Add("limit", strconv.Itoa(req.Limit))
-	} else {
-		req.Limit = 1
+	if req.Stars != 0 {
+		kept := resp[:0]
+		for _, p := range resp {
+			if p.Stars == req.Stars {
+				kept = append(kept, p)
+			}
+		}
+		resp = kept
 	}
-	v.Add("clientIp", req.CustomerIP.String())
 
-	r, err := http.Get(apiURL + endpoint + v.Encode())
-	if err != nil {
+	return &resp, nil
+}
+
+func (this *API) priceBody(ctx context.Context, req *PriceRequest) ([]byte, error) {
+	const endpoint = EndpointPrice
+	if err := req.Validate(); err != nil {
 		return nil, err
 	}
-	go this.updateRemains(r)
 
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	resp := make([]PriceResponse, req.Limit)
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return nil, err
+	v := encodeParams(req)
+	adults, children, infants := this.occupancy(req)
+	v["adults"] = strconv.Itoa(adults)
+	if children != 0 {
+		v["children"] = strconv.Itoa(children)
+	}
+	if infants != 0 {
+		v["infants"] = strconv.Itoa(infants)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = 1
 	}
+	v["limit"] = strconv.Itoa(limit)
 
-	return &resp, nil
+	return this.get(ctx, endpoint, encodeQuery(paramValues(v)), "")
+}
+
+// Returns number of guests of request, zero values are taken from defaults.
+func (this *API) occupancy(req *PriceRequest) (adults, children, infants int) {
+	this.mu.Lock()
+	adults, children, infants = this.adults, this.children, this.infants
+	this.mu.Unlock()
+	if adults == 0 {
+		adults = defaultAdults
+	}
+	if req.Adults != 0 {
+		adults = req.Adults
+	}
+	if req.Children != 0 {
+		children = req.Children
+	}
+	if req.Infants != 0 {
+		infants = req.Infants
+	}
+	return adults, children, infants
 }
 
 type Countries struct {
@@ -277,18 +841,9 @@ func (this *API) Countries() (*[]Countries, error) {
 	if err := this.checkAccess(); err != nil {
 		return nil, err
 	}
-	const endpoint = "static/countries.json?"
-	r, err := http.Get(apiURL + endpoint + this.withSignature(nil))
-	if err != nil {
-		return nil, err
-	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 	resp := make([]Countries, 1)
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return nil, ErrNoAccess
+	if err := this.fetchStatic(context.Background(), StaticCountries, &resp); err != nil {
+		return nil, err
 	}
 	return &resp, nil
 }
@@ -309,19 +864,9 @@ func (this *API) Cities() (*[]Cities, error) {
 	if err := this.checkAccess(); err != nil {
 		return nil, err
 	}
-	const endpoint = "static/locations.json?"
-	r, err := http.Get(apiURL + endpoint + this.withSignature(nil))
-	if err != nil {
-		return nil, err
-	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
-
 	resp := make([]Cities, 2)
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return nil, ErrNoAccess
+	if err := this.fetchStatic(context.Background(), StaticCities, &resp); err != nil {
+		return nil, err
 	}
 	return &resp, nil
 }
@@ -338,18 +883,9 @@ func (this *API) Amenities() ([]Amenity, error) {
 	if err := this.checkAccess(); err != nil {
 		return nil, err
 	}
-	const endpoint = "static/amenities.json?"
-	r, err := http.Get(apiURL + endpoint + this.withSignature(nil))
-	if err != nil {
-		return nil, err
-	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 	resp := make([]Amenity, 1)
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return nil, ErrNoAccess
+	if err := this.fetchStatic(context.Background(), StaticAmenities, &resp); err != nil {
+		return nil, err
 	}
 	return resp, nil
 }
@@ -359,32 +895,34 @@ type HotelList struct {
 	Hotels    []Hotel `json:"hotels"`
 }
 
+type HotelLocation struct {
+	Latitude float64 `json:"lat"`
+	Logitude float64 `json:"lon"`
+}
+
 type Hotel struct {
-	ID            int     `json:"id"`
-	CityID        int     `json:"cityId"`
-	Stars         int     `json:"stars"`
-	PriceFrom     int     `json:"pricefrom"`
-	Rating        int     `json:"rating"`
-	Popularity    int     `json:"popularity"`
-	PropertyType  int     `json:"propertyType"`
-	CheckIn       string  `json:"checkIn"`
-	CheckOut      string  `json:"checkOut"`
-	Distance      float64 `json:"distance"`
-	YearOpened    int     `json:"yearOpened"`
-	YearRenovated int     `json:"yearRenovated"`
-	PhotoCount    int     `json:"photoCount"`
+	ID            int          `json:"id"`
+	CityID        int          `json:"cityId"`
+	Stars         int          `json:"stars"`
+	PriceFrom     int          `json:"pricefrom"`
+	Rating        int          `json:"rating"`
+	Popularity    int          `json:"popularity"`
+	PropertyType  PropertyType `json:"propertyType"`
+	CheckIn       string       `json:"checkIn"`
+	CheckOut      string       `json:"checkOut"`
+	Distance      float64      `json:"distance"`
+	YearOpened    int          `json:"yearOpened"`
+	YearRenovated int          `json:"yearRenovated"`
+	PhotoCount    int          `json:"photoCount"`
 	Photos        []struct {
 		URL    string `json:"url"`
 		Width  int    `json:"width"`
 		Height int    `json:"height"`
 	} `json:"photos"`
-	Facilities      []int    `json:"facilities"`
-	ShortFacilities []string `json:"shortFacilities"`
-	Location        struct {
-		Latitude float64 `json:"lat"`
-		Logitude float64 `json:"lon"`
-	} `json:"location"`
-	Name struct {
+	Facilities      []int         `json:"facilities"`
+	ShortFacilities []string      `json:"shortFacilities"`
+	Location        HotelLocation `json:"location"`
+	Name            struct {
 		EN string `json:"en"`
 		RU string `json:"ru,omitempty"`
 	} `json:"name"`
@@ -400,25 +938,28 @@ type Hotel struct {
 // Fetch hotel list
 // Watch https://support.travelpayouts.com/hc/ru/articles/203956133-API-поиска-отелей#44
 func (this *API) FetchHotelList(locationId string) (*HotelList, error) {
+	return this.fetchHotelList(context.Background(), locationId)
+}
+
+func (this *API) fetchHotelList(ctx context.Context, locationId string) (*HotelList, error) {
 	if err := this.checkAccess(); err != nil {
 		return nil, err
 	}
 	v := make(map[string]string)
 	v["locationId"] = locationId
 
-	const endpoint = "static/hotels.json?"
-	r, err := http.Get(apiURL + endpoint + this.withSignature(v))
+	const endpoint = EndpointHotels
+	body, err := this.get(ctx, endpoint, this.withSignature(v), "")
 	if err != nil {
 		return &HotelList{}, err
 	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 
 	resp := new(HotelList)
-	if err = ffjson.NewDecoder().Decode(body, resp); err != nil {
-		return &HotelList{}, ErrNoAccess
+	if err = this.decode(body, resp); err != nil {
+		if errors.Is(err, ErrUnexpectedField) {
+			return &HotelList{}, wrapErr(endpoint, err)
+		}
+		return &HotelList{}, wrapErr(endpoint, ErrNoAccess)
 	}
 	return resp, nil
 }
@@ -426,192 +967,256 @@ func (this *API) FetchHotelList(locationId string) (*HotelList, error) {
 // Fetch room types.
 // Watch https://support.travelpayouts.com/hc/ru/articles/203956133-API-поиска-отелей#45
 func (this *API) RoomTypes() (*interface{}, error) {
-	const endpoint = "static/roomTypes.json?"
-	r, err := http.Get(apiURL + endpoint + this.withSignature(nil))
-	if err != nil {
-		return nil, err
-	}
-	go this.updateRemains(r)
-
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 	resp := new(interface{})
-	if err = ffjson.NewDecoder().Decode(body, resp); err != nil {
-		return nil, ErrNoAccess
+	if err := this.fetchStatic(context.Background(), StaticRoomTypes, resp); err != nil {
+		return nil, err
 	}
 	return resp, nil
 }
 
 func (this *API) PhotoLink(hotelId, photoId int, size string) string {
+	return photoLink(hotelId, photoId, size)
+}
+
+func photoLink(hotelId, photoId int, size string) string {
 	return fmt.Sprintf("https://photo.hotellook.com/image_v2/limit/h%d_%d/%s.jpg", hotelId, photoId, size)
 }
 
 type SearchRequest struct {
-	CityID        int
-	HotelID       int
-	IATA          string
-	CheckIn       string
-	CheckOut      string
+	CityID        int    `query:"cityId"`
+	HotelID       int    `query:"hotelId,omitempty"`
+	IATA          string `query:"iata,omitempty"`
+	CheckIn       string `query:"checkIn"`
+	CheckOut      string `query:"checkOut"`
 	AdultsCount   int
 	ChildrenCount int
 	ChildAges     [3]int
-	CustomerIp    string
+	CustomerIP    net.IP `query:"customerIp,omitempty"`
 	Currency      string
-	Lang          string
-	WaitForResult int
+	Lang          string `query:"lang"`
+	WaitForResult int    `query:"waitForResult,omitempty"`
+	// Occupancy of every room for multi-room search, AdultsCount,
+	// ChildrenCount and ChildAges should be left zero then.
+	Rooms []RoomOccupancy
 }
 
-func (this *API) Search(req *SearchRequest) (int, error) {
-	const endpoint = "search/start.json?"
+// Guests of one room of multi-room search.
+type RoomOccupancy struct {
+	AdultsCount   int
+	ChildrenCount int
+	ChildAges     [3]int
+}
 
-	v := make(map[string]string)
-	// if req.IATA == "" && (req.CityID == 0 || req.HotelID == 0) {
-	// 	return "", ErrMissingParams
-	// }
-	v["cityId"] = strconv.Itoa(req.CityID)
-	if req.HotelID != 0 {
-		v["hotelId"] = strconv.Itoa(req.HotelID)
+// Adds numbers of guests and ages of children. Params of rooms
+// of multi-room search are prefixed (e.g. "rooms[0][adultsCount]").
+func addOccupancy(v map[string]string, prefix string, adults, children int, ages [3]int) {
+	key := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "[" + name + "]"
+	}
+	v[key("adultsCount")] = strconv.Itoa(adults)
+	v[key("childrenCount")] = strconv.Itoa(children)
+	for i := 0; i < children && i < len(ages); i++ {
+		v[key("childAge"+strconv.Itoa(i+1))] = strconv.Itoa(ages[i])
 	}
-	if req.WaitForResult != 0 {
-		v["waitForResult"] = strconv.Itoa(req.WaitForResult)
+}
+
+// Response of search start.
+type SearchStartResponse struct {
+	SearchID int    `json:"searchId"`
+	Status   string `json:"status"`
+}
+
+// Starts search and returns its ID, see StartSearch.
+func (this *API) Search(req *SearchRequest, opts ...RequestOption) (int, error) {
+	resp, err := this.StartSearch(req, opts...)
+	if err != nil {
+		return 0, err
 	}
-	if req.IATA != "" {
-		v["iata"] = req.IATA
+	return resp.SearchID, nil
+}
+
+// Starts search, results can be fetched by FetchSearchResults with returned ID.
+func (this *API) StartSearch(req *SearchRequest, opts ...RequestOption) (*SearchStartResponse, error) {
+	const endpoint = EndpointSearch
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
-	v["checkIn"] = req.CheckIn
-	v["checkOut"] = req.CheckOut
-	v["adultsCount"] = strconv.Itoa(req.AdultsCount)
 
-	v["childrenCount"] = strconv.Itoa(req.ChildrenCount)
-	if req.ChildrenCount != 0 {
-		v["childAge1"] = strconv.Itoa(req.ChildAges[0])
-		if req.ChildrenCount > 1 {
-			v["childAge2"] = strconv.Itoa(req.ChildAges[1])
-		}
-		if req.ChildrenCount == 3 {
-			v["childAge3"] = strconv.Itoa(req.ChildAges[2])
+	// if req.IATA == "" && (req.CityID == 0 || req.HotelID == 0) {
+	// 	return "", ErrMissingParams
+	// }
+	v := encodeParams(req)
+	if len(req.Rooms) == 0 {
+		addOccupancy(v, "", req.AdultsCount, req.ChildrenCount, req.ChildAges)
+	} else {
+		v["roomsCount"] = strconv.Itoa(len(req.Rooms))
+		for i, room := range req.Rooms {
+			prefix := "rooms[" + strconv.Itoa(i) + "]"
+			addOccupancy(v, prefix, room.AdultsCount, room.ChildrenCount, room.ChildAges)
 		}
 	}
-	v["lang"] = req.Lang
 	v["currency"] = strings.ToUpper(req.Currency)
-	v["customerIp"] = req.CustomerIp
 
-	r, err := http.Get(apiURL + endpoint + this.withSignature(v))
+	ctx := withRequestOptions(context.Background(), opts)
+	body, err := this.get(ctx, endpoint, this.withSignature(v), req.Lang)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	go this.updateRemains(r)
-	var resp struct {
-		SearchID int    `json:"searchId"`
-		Status   string `json:"status"`
+	resp := new(SearchStartResponse)
+	if err = this.decode(body, resp); err != nil {
+		return nil, wrapErr(endpoint, err)
 	}
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return 0, err
+	// Error status is reported by checkResponse, but search may still
+	// be not started without any explanation.
+	if resp.SearchID <= 0 {
+		return nil, wrapErr(endpoint, ErrEmptySearchID)
 	}
-	return resp.SearchID, nil
+	return resp, nil
 }
 
 type SearchResultsRequest struct {
-	SearchID int // required
-	Limit    int
-	Offset   int
+	SearchID int `query:"searchId"` // required
+	Limit    int `query:"limit,omitempty"`
+	Offset   int `query:"offset,omitempty"`
 	// Sorty by [popularity|price|name|guestScore|stars]
-	SortBy string
+	SortBy string `query:"sortBy,omitempty"`
 	// If you want to sort results by descending, set it equal to -1.
 	SortAsc    int
-	RoomsCount int
+	RoomsCount int `query:"roomsCount,omitempty"`
+	// Return only rooms with these options, see Room.Options. API has no
+	// such params, so rooms are filtered after response, and hotels without
+	// matching rooms are dropped.
+	Breakfast  bool
+	Refundable bool
+	FreeWifi   bool
+	// Return only hotels with that many stars (1-5), zero means any.
+	// API has no such param, so hotels are filtered after response
+	// and less than Limit of them may be returned.
+	Stars int
 }
 
 type SearchResults struct {
-	Status  string `json:"status"`
-	Results []struct {
-		FullURL          string `json:"fullUrl"`          // ссылка на отель с вашим партнерским маркером
-		MaxPricePerNight int    `json:"maxPricePerNight"` // максимальная цена за ночь;
-		MinPriceTotal    int    `json:"minPriceTotal"`
-		MaxPrice         int    `json:"maxPrice"`
-		PhotoCount       int    `json:"photoCount"`
-		GuestScore       int    `json:"guestScore"`
-		Address          string `json:"address"`
-		ID               int    `json:"id"`
-		Price            int    `json:"price"` // средняя цена за номер;
-		Name             string `json:"name"`
-		URL              string `json:"url"`
-		Popularity       int    `json:"popularity"`
-		Location         struct {
-			Lat float64 `json:"lat"`
-			Lon float64 `json:"lon"`
-		} `json:"location"`
-		Stars    int     `json:"stars"`
-		Distance float64 `json:"distance"` // расстояние от отеля до центра города;
-		Rooms    []struct {
-			AgencyID       string  `json:"agencyId"`
-			AgencyName     string  `json:"agencyName"`
-			BookingURL     string  `json:"bookingURL"`
-			Type           string  `json:"type"`
-			Tax            float64 `json:"tax"`
-			Total          int     `json:"total"`
-			Price          int     `json:"price"`
-			FullBookingURL string  `json:"fullBookingURL"`
-			Rating         int     `json:"rating"`
-			Description    string  `json:"desc"`
-			Options        struct {
-				Available    int  `json:"available"`    // количество оставшихся комнат;
-				Breakfast    bool `json:"breakfast"`    // включён ли завтрак;
-				Refundable   bool `json":"refundable"`  // возможность возврата;
-				Deposit      bool `json:"deposit"`      // оплата на сайте OTA (при бронировании);
-				CardRequired bool `json:"cardRequired"` // обязательно наличие банковской карты;
-				Smoking      bool `json:"smoking"`      // можно ли курить в номере;
-				FreeWifi     bool `json:"freeWifi"`     // есть ли бесплатный wifi в номере;
-				HotelWebsite bool `json:"hotelWebsite"` // предложение ведёт на официальный сайт отеля.
-			} `json:"options"`
-		} `json:"rooms"`
-	} `json:"result"`
-}
-
-func (this *API) FetchSearchResults(req *SearchResultsRequest) (*SearchResults, error) {
-	const endpoint = "search/getResult.json?"
-	v := make(map[string]string)
-	if req.SearchID == 0 {
-		return nil, ErrEmptySearchID
-	}
-	if req.SearchID == -1 {
-		body, _ := ioutil.ReadFile("./test_data.json")
-		var resp SearchResults
-		if err := ffjson.NewDecoder().Decode(body, &resp); err != nil {
-			return &resp, err
-		}
-		return &resp, nil
-	}
-	v["searchId"] = strconv.Itoa(req.SearchID)
-	if req.Limit != 0 {
-		v["limit"] = strconv.Itoa(req.Limit)
-	}
-	if req.Offset != 0 {
-		v["offset"] = strconv.Itoa(req.Offset)
+	Status  string         `json:"status"`
+	Results []SearchResult `json:"result"`
+	// Currency of prices, taken from booking links since it's set
+	// by Search and is not returned explicitly.
+	Currency string `json:"currency,omitempty"`
+	// Set by FetchAllSearchResults if not all hotels were kept,
+	// see MaxAccumulatedResults.
+	Truncated bool `json:"-"`
+	// Set by FetchSearchResultsTimeout if search was not finished in time.
+	Partial bool `json:"-"`
+}
+
+// Hotel found by search with its room offers.
+type SearchResult struct {
+	FullURL          string      `json:"fullUrl"`          // ссылка на отель с вашим партнерским маркером
+	MaxPricePerNight int         `json:"maxPricePerNight"` // максимальная цена за ночь;
+	MinPriceTotal    int         `json:"minPriceTotal"`
+	MaxPrice         int         `json:"maxPrice"`
+	PhotoCount       int         `json:"photoCount"`
+	GuestScore       int         `json:"guestScore"`
+	Address          string      `json:"address"`
+	ID               int         `json:"id"`
+	Price            int         `json:"price"` // средняя цена за номер;
+	Name             string      `json:"name"`
+	URL              string      `json:"url"`
+	Popularity       int         `json:"popularity"`
+	Location         Coordinates `json:"location"`
+	Stars            int         `json:"stars"`
+	Distance         float64     `json:"distance"` // расстояние от отеля до центра города;
+	Rating           int         `json:"rating"`
+	Amenities        []int       `json:"amenities"`
+	// Количество фотографий по типам номеров (ID типа номера -> количество).
+	PhotosByRoomType map[string]int `json:"photosByRoomType"`
+	Rooms            []Room         `json:"rooms"`
+	// Static data of hotel set by EnrichWithStatic, it's a copy of
+	// cached hotel list entry and may be changed.
+	Static *Hotel `json:"-"`
+}
+
+// Room offer of an agency.
+type Room struct {
+	AgencyID       string  `json:"agencyId"`
+	AgencyName     string  `json:"agencyName"`
+	BookingURL     string  `json:"bookingURL"`
+	Type           string  `json:"type"`
+	Tax            float64 `json:"tax"`
+	Total          int     `json:"total"`
+	Price          int     `json:"price"`
+	FullBookingURL string  `json:"fullBookingURL"`
+	Rating         int     `json:"rating"`
+	Description    string  `json:"desc"`
+	InternalTypeID string  `json:"internalTypeId"` // тип номера, см. RoomTypes
+	Options        struct {
+		Available    int  `json:"available"`    // количество оставшихся комнат;
+		Breakfast    bool `json:"breakfast"`    // включён ли завтрак;
+		Refundable   bool `json:"refundable"`   // возможность возврата;
+		Deposit      bool `json:"deposit"`      // оплата на сайте OTA (при бронировании);
+		CardRequired bool `json:"cardRequired"` // обязательно наличие банковской карты;
+		Smoking      bool `json:"smoking"`      // можно ли курить в номере;
+		FreeWifi     bool `json:"freeWifi"`     // есть ли бесплатный wifi в номере;
+		HotelWebsite bool `json:"hotelWebsite"` // предложение ведёт на официальный сайт отеля.
+		Dormitory    bool `json:"dormitory"`    // койко-место в общем номере;
+		Bedrooms     int  `json:"bedrooms"`     // количество спален;
+		// Кровати по типам (single, double, singleOrDouble) -> количество.
+		Beds map[string]int `json:"beds"`
+	} `json:"options"`
+}
+
+func (this *API) FetchSearchResults(req *SearchResultsRequest, opts ...RequestOption) (*SearchResults, error) {
+	return this.fetchSearchResults(withRequestOptions(context.Background(), opts), req)
+}
+
+func (this *API) fetchSearchResults(ctx context.Context, req *SearchResultsRequest) (*SearchResults, error) {
+	resp, err := this.fetchSearchResultsPage(ctx, req)
+	if err == nil {
+		req.filter(resp)
 	}
-	if req.SortBy != "" {
-		v["sortBy"] = req.SortBy
+	return resp, err
+}
+
+// Same as fetchSearchResults, but filters of req which API doesn't support
+// are not applied, so page size is known.
+func (this *API) fetchSearchResultsPage(ctx context.Context, req *SearchResultsRequest) (*SearchResults, error) {
+	const endpoint = EndpointSearchResults
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
+	v := encodeParams(req)
 	if req.SortAsc == -1 {
 		v["sortAsc"] = "0"
 	}
-	if req.RoomsCount != 0 {
-		v["roomsCount"] = strconv.Itoa(req.RoomsCount)
-	}
 
-	r, err := http.Get(apiURL + endpoint + this.withSignature(v))
+	body, err := this.get(ctx, endpoint, this.withSignature(v), "")
 	if err != nil {
 		return &SearchResults{}, err
 	}
-	go this.updateRemains(r)
+	resp, err := decodeSearchResults(body, this.decode)
+	if err != nil {
+		return &SearchResults{}, wrapErr(endpoint, err)
+	}
+	this.rewriteLinks(resp)
+	return resp, nil
+}
 
-	var resp SearchResults
-	body, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	if err = ffjson.NewDecoder().Decode(body, &resp); err != nil {
-		return &SearchResults{}, err
+// Decodes response of FetchSearchResults, e.g. saved earlier.
+func DecodeSearchResults(r io.Reader) (*SearchResults, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
-	return &resp, nil
+	return decodeSearchResults(body, ffjson.NewDecoder().Decode)
+}
+
+func decodeSearchResults(body []byte, decode func([]byte, interface{}) error) (*SearchResults, error) {
+	resp := new(SearchResults)
+	if err := decode(body, resp); err != nil {
+		return nil, err
+	}
+	resp.fillCurrency()
+	return resp, nil
 }
@@ -1,7 +1,16 @@
 package hotellook
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -13,6 +22,27 @@ const (
 	validToken  = "YOUR_APPROVED_TOKEN"
 )
 
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// Returns API which sends all requests to fn instead of network.
+func mockAPI(fn roundTripFunc) *API {
+	api := NewAPI(validMarker)
+	api.SetToken(validToken)
+	api.client = &http.Client{Transport: fn}
+	return api
+}
+
+// Response with status 200 and given body.
+func mockResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
 func TestNewAPI(t *testing.T) {
 	api := NewAPI(marker)
 	if api == nil {
@@ -52,6 +82,176 @@ func TestWithSignature(t *testing.T) {
 	}
 }
 
+func TestSignatureHash(t *testing.T) {
+	api := NewAPI(marker)
+	api.SetToken(token)
+	api.SetSignatureHash(sha256.New)
+	sum := sha256.Sum256([]byte(token + ":35290"))
+	if encoded := api.withSignature(nil); encoded != "marker=35290&signature="+hex.EncodeToString(sum[:]) {
+		t.Fatal("withSignature doesn't use configured hash, got " + encoded)
+	}
+
+	api.SetSignatureHash(nil)
+	if encoded := api.withSignature(nil); encoded != "marker=35290&signature=abdab6a981233bdaf156a5abc17cb382" {
+		t.Fatal("withSignature should use MD5 by default, got " + encoded)
+	}
+}
+
+func TestWithSignatureEscaping(t *testing.T) {
+	api := NewAPI(marker)
+	api.SetToken(token)
+
+	v := make(map[string]string)
+	v["query"] = "Saint Petersburg"
+	v["hotel"] = "A+B & Co/100%"
+	encoded := api.withSignature(v)
+	if strings.Contains(encoded, "+") {
+		t.Fatal("withSignature should escape spaces as %20, got " + encoded)
+	}
+
+	q, err := url.ParseQuery(encoded)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for k, val := range v {
+		if q.Get(k) != val {
+			t.Fatalf("value of %q changed after unescaping: %q, expected %q", k, q.Get(k), val)
+		}
+	}
+
+	sum := md5.Sum([]byte(token + ":35290:A+B & Co/100%:Saint Petersburg"))
+	if q.Get("signature") != hex.EncodeToString(sum[:]) {
+		t.Fatal("signature doesn't match raw values, got " + q.Get("signature"))
+	}
+}
+
+func TestSetTokenConcurrent(t *testing.T) {
+	tokens := []string{"token-a", "token-b"}
+	valid := make(map[string]bool)
+	for _, tok := range tokens {
+		sum := md5.Sum([]byte(tok + ":" + strconv.Itoa(validMarker)))
+		valid[hex.EncodeToString(sum[:])] = true
+	}
+	var mu sync.Mutex
+	var invalid []string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if sig := r.URL.Query().Get("signature"); !valid[sig] {
+			mu.Lock()
+			invalid = append(invalid, sig)
+			mu.Unlock()
+		}
+		return mockResponse(`[]`), nil
+	})
+	api.SetToken(tokens[0])
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				api.Countries()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		api.SetToken(tokens[i%2])
+	}
+	wg.Wait()
+	if len(invalid) != 0 {
+		t.Fatalf("%d requests were signed with inconsistent credentials", len(invalid))
+	}
+}
+
+func TestUpdateRemains(t *testing.T) {
+	api := NewAPI(marker)
+	r := &http.Response{Header: http.Header{}}
+	r.Header.Set("X-Ratelimit-Remaining", "42")
+	r.Header.Set("X-Ratelimit-Limit", "100")
+	api.updateRemains(r)
+	if api.RequestsRemains() != 42 || api.RequestsLimit() != 100 {
+		t.Fatalf("got remains=%d limit=%d, expected 42 and 100", api.RequestsRemains(), api.RequestsLimit())
+	}
+
+	api.updateRemains(&http.Response{Header: http.Header{}})
+	if api.RequestsRemains() != 42 || api.RequestsLimit() != 100 {
+		t.Fatal("updateRemains should keep previous values when headers are missing")
+	}
+
+	r.Header.Set("X-Ratelimit-Remaining", "garbage")
+	r.Header.Del("X-Ratelimit-Limit")
+	api.updateRemains(r)
+	if api.RequestsRemains() != 42 {
+		t.Fatal("updateRemains should keep previous value when header is malformed")
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	const body = `[{"id":"1","code":"RU"},{"id":"2","code":"US"}]`
+	r := &http.Response{
+		ContentLength: int64(len(body)) + 100,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+	}
+	if _, err := readBody(r, 0); err != ErrTruncatedResponse {
+		t.Fatalf("readBody returns %v on short body, expected ErrTruncatedResponse", err)
+	}
+
+	for _, length := range []int64{int64(len(body)), -1} {
+		r = &http.Response{
+			ContentLength: length,
+			Body:          ioutil.NopCloser(strings.NewReader(body)),
+		}
+		b, err := readBody(r, 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(b) != body {
+			t.Fatal("readBody returns unexpected body " + string(b))
+		}
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[{"id":"1","name":"` + strings.Repeat("x", 100) + `"}]`), nil
+	})
+	api.SetMaxResponseBytes(64)
+	if _, err := api.Lookup(&LookupRequest{Query: "moscow"}); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Lookup returns %v on large body, expected ErrResponseTooLarge", err)
+	}
+	if _, err := api.Amenities(); err != nil {
+		t.Fatal("static endpoints should not be limited: " + err.Error())
+	}
+
+	api.SetMaxResponseBytes(0)
+	if _, err := api.Price(&PriceRequest{Location: "MOW"}); errors.Is(err, ErrResponseTooLarge) {
+		t.Fatal("zero limit should disable the check")
+	}
+}
+
+func TestAcceptLanguage(t *testing.T) {
+	var got string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		got = r.Header.Get("Accept-Language")
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+
+	if _, err := api.Lookup(&LookupRequest{Query: "moscow", Lang: "de"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != "de" {
+		t.Fatalf("Accept-Language is %q, expected \"de\"", got)
+	}
+
+	api.SetHeader("Accept-Language", "fr")
+	if _, err := api.Lookup(&LookupRequest{Query: "moscow", Lang: "de"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != "fr" {
+		t.Fatalf("Accept-Language is %q, expected value set by SetHeader", got)
+	}
+}
+
 func TestLookup(t *testing.T) {
 	api := NewAPI(marker)
 	api.SetToken(token)
@@ -81,6 +281,197 @@ func TestPrice(t *testing.T) {
 	}
 }
 
+func TestPriceDefaultLimit(t *testing.T) {
+	var limit string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		limit = r.URL.Query().Get("limit")
+		return mockResponse(`[{"hotelId":1,"priceFrom":100}]`), nil
+	})
+	resp, err := api.Price(&PriceRequest{
+		Location: "MOW",
+		CheckIn:  "2016-12-10",
+		CheckOut: "2016-12-17",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if limit != "1" {
+		t.Fatalf("limit param is %q, expected default \"1\"", limit)
+	}
+	if len(*resp) != 1 || (*resp)[0].HotelID != 1 {
+		t.Fatalf("got %d prices, expected exactly one", len(*resp))
+	}
+}
+
+func TestDefaultOccupancy(t *testing.T) {
+	var query url.Values
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return mockResponse(`[]`), nil
+	})
+	req := &PriceRequest{
+		Location: "MOW",
+		CheckIn:  "2016-12-10",
+		CheckOut: "2016-12-17",
+	}
+	if _, err := api.Price(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	if query.Get("adults") != "2" || query.Get("children") != "" || query.Get("infants") != "" {
+		t.Fatalf("got query %v, expected 2 adults only", query)
+	}
+
+	api.SetDefaultOccupancy(1, 2, 1)
+	if _, err := api.Price(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	if query.Get("adults") != "1" || query.Get("children") != "2" || query.Get("infants") != "1" {
+		t.Fatalf("got query %v, expected client defaults", query)
+	}
+
+	req.Adults, req.Children = 3, 1
+	if _, err := api.Price(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	if query.Get("adults") != "3" || query.Get("children") != "1" || query.Get("infants") != "1" {
+		t.Fatalf("got query %v, expected request values over defaults", query)
+	}
+}
+
+func TestRetryOnEmptyBody(t *testing.T) {
+	var calls int
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return mockResponse(""), nil
+		}
+		return mockResponse(`[{"hotelId":1,"priceFrom":100}]`), nil
+	})
+	api.RetryOnEmptyBody(EndpointPrice)
+	resp, err := api.Price(&PriceRequest{
+		Location: "MOW",
+		CheckIn:  "2016-12-10",
+		CheckOut: "2016-12-17",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, expected one retry", calls)
+	}
+	if len(*resp) != 1 || (*resp)[0].HotelID != 1 {
+		t.Fatalf("got %d prices, expected response of second call", len(*resp))
+	}
+
+	calls = 0
+	api.Lookup(&LookupRequest{Query: "moscow"})
+	if calls != 1 {
+		t.Fatalf("got %d lookup calls, expected no retry", calls)
+	}
+}
+
+func TestLookupConvertCase(t *testing.T) {
+	var query url.Values
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+	cases := []struct {
+		req      LookupRequest
+		sent     bool
+		expected string
+	}{
+		{LookupRequest{Query: "moscow"}, false, ""},
+		{LookupRequest{Query: "moscow", ConvertCase: 1}, true, "1"},
+		{LookupRequest{Query: "moscow", DisableConvertCase: true}, true, "0"},
+		{LookupRequest{Query: "moscow", ConvertCase: 1, DisableConvertCase: true}, true, "0"},
+	}
+	for _, c := range cases {
+		if _, err := api.Lookup(&c.req); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, sent := query["convertCase"]; sent != c.sent || query.Get("convertCase") != c.expected {
+			t.Fatalf("request %+v sent convertCase %q", c.req, query["convertCase"])
+		}
+	}
+}
+
+func TestStartSearch(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`{"searchId": 4034914, "status": "ok"}`), nil
+	})
+	req := &SearchRequest{CityID: 12153, CheckIn: "2016-12-10", CheckOut: "2016-12-17", AdultsCount: 2}
+	resp, err := api.StartSearch(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.SearchID != 4034914 || resp.Status != "ok" {
+		t.Fatalf("got %+v, expected search 4034914 with status ok", resp)
+	}
+	if id, err := api.Search(req); err != nil || id != 4034914 {
+		t.Fatalf("Search returned %d, %v", id, err)
+	}
+}
+
+func TestStartSearchFailed(t *testing.T) {
+	body := `{"searchId": 0, "status": "error", "message": "Wrong checkIn date"}`
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(body), nil
+	})
+	req := &SearchRequest{CityID: 12153, CheckIn: "2016-12-10", CheckOut: "2016-12-17", AdultsCount: 2}
+	id, err := api.Search(req)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Wrong checkIn date" || id != 0 {
+		t.Fatalf("Search returned %d, %v; expected API error", id, err)
+	}
+
+	body = `{"searchId": 0, "status": "ok"}`
+	if _, err = api.StartSearch(req); !errors.Is(err, ErrEmptySearchID) {
+		t.Fatalf("got %v, expected ErrEmptySearchID for response without search ID", err)
+	}
+}
+
+func TestMultiRoomSearch(t *testing.T) {
+	var query url.Values
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return mockResponse(`{"searchId": 1, "status": "ok"}`), nil
+	})
+	req := &SearchRequest{
+		CityID:   12153,
+		CheckIn:  "2016-12-10",
+		CheckOut: "2016-12-17",
+		Rooms: []RoomOccupancy{
+			{AdultsCount: 2},
+			{AdultsCount: 1, ChildrenCount: 2, ChildAges: [3]int{5, 11}},
+		},
+	}
+	if _, err := api.Search(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := map[string]string{
+		"roomsCount":              "2",
+		"rooms[0][adultsCount]":   "2",
+		"rooms[0][childrenCount]": "0",
+		"rooms[1][adultsCount]":   "1",
+		"rooms[1][childrenCount]": "2",
+		"rooms[1][childAge1]":     "5",
+		"rooms[1][childAge2]":     "11",
+		"adultsCount":             "",
+		"rooms[1][childAge3]":     "",
+	}
+	for k, val := range expected {
+		if query.Get(k) != val {
+			t.Fatalf("param %s is %q, expected %q", k, query.Get(k), val)
+		}
+	}
+
+	req.AdultsCount = 2
+	if _, err := api.Search(req); err != ErrConflictingParams {
+		t.Fatalf("got %v, expected ErrConflictingParams for adults outside of rooms", err)
+	}
+}
+
 func TestCountries(t *testing.T) {
 	api := NewAPI(validMarker)
 	api.SetToken(validToken)
@@ -125,12 +516,37 @@ func TestRoomTypes(t *testing.T) {
 }
 
 func TestFetchSearchResults(t *testing.T) {
-	api := NewAPI(validMarker)
-	api.SetToken(validToken)
-	if _, err := api.FetchSearchResults(&SearchResultsRequest{
-		SearchID: "-1",
-	}); err != nil {
+	body, err := ioutil.ReadFile("./test_data.json")
+	if err != nil {
 		t.Fatal(err.Error())
 	}
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(string(body)), nil
+	})
+	resp, err := api.FetchSearchResults(&SearchResultsRequest{SearchID: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Len() != 28 || resp.Currency != "USD" {
+		t.Fatalf("got %d hotels in %q, expected 28 in USD", resp.Len(), resp.Currency)
+	}
+}
 
+func TestSignatureSource(t *testing.T) {
+	for _, tc := range []struct {
+		token  string
+		marker int
+		params []string
+		src    string
+	}{
+		{"token", 35290, nil, "token:35290"},
+		{"token", 35290, []string{"12153"}, "token:35290:12153"},
+		{"token", 35290, []string{"2016-12-10", "2016-12-13", "ru", "moscow"}, "token:35290:2016-12-10:2016-12-13:ru:moscow"},
+		{"token", 35290, []string{"", "1"}, "token:35290::1"},
+		{"", 0, nil, ":0"},
+	} {
+		if src := signatureSource(tc.token, tc.marker, tc.params); src != tc.src {
+			t.Fatalf("signatureSource(%q, %d, %q) = %q, expected %q", tc.token, tc.marker, tc.params, src, tc.src)
+		}
+	}
 }
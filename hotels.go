@@ -0,0 +1,261 @@
+package hotellook
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Type of property, see Hotel.PropertyType.
+type PropertyType int
+
+const (
+	PropertyOther PropertyType = iota
+	PropertyHotel
+	PropertyApartmentHotel
+	PropertyBedAndBreakfast
+	PropertyApartment
+	PropertyMotel
+	PropertyGuestHouse
+	PropertyHostel
+	PropertyResort
+	PropertyFarm
+	PropertyVacation
+	PropertyLodge
+	PropertyVilla
+)
+
+var propertyTypeNames = map[PropertyType]string{
+	PropertyOther:           "other",
+	PropertyHotel:           "hotel",
+	PropertyApartmentHotel:  "apartment hotel",
+	PropertyBedAndBreakfast: "bed and breakfast",
+	PropertyApartment:       "apartment",
+	PropertyMotel:           "motel",
+	PropertyGuestHouse:      "guest house",
+	PropertyHostel:          "hostel",
+	PropertyResort:          "resort",
+	PropertyFarm:            "farm",
+	PropertyVacation:        "vacation rental",
+	PropertyLodge:           "lodge",
+	PropertyVilla:           "villa",
+}
+
+func (this PropertyType) String() string {
+	if name, ok := propertyTypeNames[this]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+var unknownPropertyTypes struct {
+	sync.Mutex
+	hook func(PropertyType)
+	seen map[PropertyType]bool
+}
+
+// Sets function called when decoded hotel has property type unknown to this
+// package, e.g. to log it. It's called once per code, nil disables it.
+func SetUnknownPropertyTypeHook(fn func(PropertyType)) {
+	unknownPropertyTypes.Lock()
+	unknownPropertyTypes.hook = fn
+	unknownPropertyTypes.seen = nil
+	unknownPropertyTypes.Unlock()
+}
+
+func (this *PropertyType) UnmarshalJSON(data []byte) error {
+	var code int
+	if err := json.Unmarshal(data, &code); err != nil {
+		return err
+	}
+	*this = PropertyType(code)
+	if _, ok := propertyTypeNames[*this]; ok {
+		return nil
+	}
+
+	u := &unknownPropertyTypes
+	u.Lock()
+	hook := u.hook
+	report := hook != nil && !u.seen[*this]
+	if report {
+		if u.seen == nil {
+			u.seen = make(map[PropertyType]bool)
+		}
+		u.seen[*this] = true
+	}
+	u.Unlock()
+	if report {
+		hook(*this)
+	}
+	return nil
+}
+
+// Returns hotels opened in minOpened or later, or renovated in minRenovated
+// or later. Zero value disables the corresponding condition.
+func (this *HotelList) FilterByYear(minOpened, minRenovated int) []Hotel {
+	var hotels []Hotel
+	for _, h := range this.Hotels {
+		if minOpened == 0 && minRenovated == 0 ||
+			minOpened > 0 && h.YearOpened >= minOpened ||
+			minRenovated > 0 && h.YearRenovated >= minRenovated {
+			hotels = append(hotels, h)
+		}
+	}
+	return hotels
+}
+
+// Returns hotels having all required facilities (see Amenities for IDs),
+// copy of all hotels if nothing is required.
+func (this *HotelList) FilterByFacilities(required []int) []Hotel {
+	if len(required) == 0 {
+		return append([]Hotel(nil), this.Hotels...)
+	}
+	var hotels []Hotel
+	for _, h := range this.Hotels {
+		has := make(map[int]bool, len(h.Facilities))
+		for _, f := range h.Facilities {
+			has[f] = true
+		}
+		matches := true
+		for _, f := range required {
+			if !has[f] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			hotels = append(hotels, h)
+		}
+	}
+	return hotels
+}
+
+// Size of photo on CDN as "width/height", photo is scaled to fit into it.
+type PhotoSize string
+
+const (
+	PhotoSmall  PhotoSize = "240/240"
+	PhotoMedium PhotoSize = "640/480"
+	PhotoLarge  PhotoSize = "1024/768"
+)
+
+// Returns CDN link of the largest photo of hotel, the first one if sizes
+// are unknown. Returns empty string if hotel has no photos.
+func (this *Hotel) CoverPhotoURL(size PhotoSize) string {
+	if len(this.Photos) == 0 && this.PhotoCount == 0 {
+		return ""
+	}
+	cover := 0
+	for i, p := range this.Photos {
+		largest := this.Photos[cover]
+		if p.Width*p.Height > largest.Width*largest.Height {
+			cover = i
+		}
+	}
+	return photoLink(this.ID, cover, string(size))
+}
+
+// Returns CDN links of at most max photos of hotel in their order,
+// all of them if max is zero.
+func (this *Hotel) PhotoURLs(size PhotoSize, max int) []string {
+	n := this.PhotoCount
+	if len(this.Photos) > n {
+		n = len(this.Photos)
+	}
+	return photoURLs(this.ID, n, size, max)
+}
+
+// Returns links of the first n photos of hotel, at most max of them
+// unless max is zero.
+func photoURLs(id, n int, size PhotoSize, max int) []string {
+	if max > 0 && n > max {
+		n = max
+	}
+	var urls []string
+	for i := 0; i < n; i++ {
+		urls = append(urls, photoLink(id, i, string(size)))
+	}
+	return urls
+}
+
+// Hotel lists are large, so EnrichWithStatic keeps at most that many of them,
+// each for hotelListTTL.
+const (
+	maxHotelLists = 32
+	hotelListTTL  = 24 * time.Hour
+)
+
+// Hotel list of location kept by EnrichWithStatic.
+type cachedHotelList struct {
+	list    *HotelList
+	fetched time.Time
+}
+
+// Sets SearchResult.Static of found hotels from hotel list of location,
+// and fills photo count and amenities if search didn't return them.
+// Hotel list is fetched on first call for location and kept in memory for
+// a day. When lists of too many locations are kept, the oldest one is dropped.
+func (this *API) EnrichWithStatic(ctx context.Context, results *SearchResults, locationID string) error {
+	now := this.now()
+	this.mu.Lock()
+	cached := this.hotelLists[locationID]
+	this.mu.Unlock()
+	var list *HotelList
+	if cached != nil && now.Sub(cached.fetched) < hotelListTTL {
+		list = cached.list
+	} else {
+		var err error
+		if list, err = this.fetchHotelList(ctx, locationID); err != nil {
+			return err
+		}
+		this.cacheHotelList(locationID, &cachedHotelList{list: list, fetched: now})
+	}
+
+	byID := make(map[int]*Hotel, len(list.Hotels))
+	for i := range list.Hotels {
+		byID[list.Hotels[i].ID] = &list.Hotels[i]
+	}
+	for i := range results.Results {
+		r := &results.Results[i]
+		h, ok := byID[r.ID]
+		if !ok {
+			continue
+		}
+		r.Static = h.copy()
+		if r.PhotoCount == 0 {
+			r.PhotoCount = h.PhotoCount
+		}
+		if len(r.Amenities) == 0 {
+			r.Amenities = append([]int(nil), h.Facilities...)
+		}
+	}
+	return nil
+}
+
+func (this *API) cacheHotelList(locationID string, cached *cachedHotelList) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.hotelLists == nil {
+		this.hotelLists = make(map[string]*cachedHotelList)
+	}
+	if _, ok := this.hotelLists[locationID]; !ok && len(this.hotelLists) >= maxHotelLists {
+		var oldest string
+		for id, c := range this.hotelLists {
+			if oldest == "" || c.fetched.Before(this.hotelLists[oldest].fetched) {
+				oldest = id
+			}
+		}
+		delete(this.hotelLists, oldest)
+	}
+	this.hotelLists[locationID] = cached
+}
+
+// Returns copy of hotel which doesn't share slices with it.
+func (this *Hotel) copy() *Hotel {
+	h := *this
+	h.Photos = append(h.Photos[:0:0], h.Photos...)
+	h.Facilities = append([]int(nil), h.Facilities...)
+	h.ShortFacilities = append([]string(nil), h.ShortFacilities...)
+	return &h
+}
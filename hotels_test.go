@@ -0,0 +1,249 @@
+package hotellook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pquerna/ffjson/ffjson"
+)
+
+func TestPropertyType(t *testing.T) {
+	cases := map[PropertyType]string{
+		PropertyHotel:    "hotel",
+		PropertyHostel:   "hostel",
+		PropertyVilla:    "villa",
+		PropertyType(99): "unknown",
+		PropertyType(-1): "unknown",
+	}
+	for pt, name := range cases {
+		if pt.String() != name {
+			t.Fatalf("PropertyType(%d) is %q, expected %q", int(pt), pt.String(), name)
+		}
+	}
+
+	var h Hotel
+	if err := ffjson.NewDecoder().Decode([]byte(`{"propertyType":4}`), &h); err != nil {
+		t.Fatal(err.Error())
+	}
+	if h.PropertyType != PropertyApartment {
+		t.Fatalf("decoded property type is %v, expected apartment", h.PropertyType)
+	}
+}
+
+func TestUnknownPropertyTypeHook(t *testing.T) {
+	var reported []PropertyType
+	SetUnknownPropertyTypeHook(func(pt PropertyType) { reported = append(reported, pt) })
+	defer SetUnknownPropertyTypeHook(nil)
+
+	var list HotelList
+	body := `{"hotels":[{"id":1,"propertyType":1},{"id":2,"propertyType":42},{"id":3,"propertyType":42},{"id":4,"propertyType":43}]}`
+	if err := ffjson.NewDecoder().Decode([]byte(body), &list); err != nil {
+		t.Fatal(err.Error())
+	}
+	if list.Hotels[1].PropertyType != 42 || list.Hotels[1].PropertyType.String() != "unknown" {
+		t.Fatalf("unknown property type decoded as %v", list.Hotels[1].PropertyType)
+	}
+	if len(reported) != 2 || reported[0] != 42 || reported[1] != 43 {
+		t.Fatalf("hook got %d codes, expected each unknown code once", len(reported))
+	}
+
+	if err := ffjson.NewDecoder().Decode([]byte(`{"propertyType":"hotel"}`), new(Hotel)); err == nil {
+		t.Fatal("non-numeric property type should not be decoded")
+	}
+}
+
+func TestFilterByYear(t *testing.T) {
+	list := &HotelList{Hotels: []Hotel{
+		{ID: 1, YearOpened: 1975, YearRenovated: 2015},
+		{ID: 2, YearOpened: 2012},
+		{ID: 3, YearOpened: 1990, YearRenovated: 2001},
+		{ID: 4},
+	}}
+	cases := []struct {
+		opened, renovated int
+		ids               []int
+	}{
+		{0, 0, []int{1, 2, 3, 4}},
+		{2010, 0, []int{2}},
+		{0, 2010, []int{1}},
+		{2010, 2010, []int{1, 2}},
+		{1980, 2000, []int{1, 2, 3}},
+		{2020, 2020, nil},
+	}
+	for _, c := range cases {
+		hotels := list.FilterByYear(c.opened, c.renovated)
+		var ids []int
+		for _, h := range hotels {
+			ids = append(ids, h.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.ids) {
+			t.Fatalf("FilterByYear(%d, %d) returns %v, expected %v", c.opened, c.renovated, ids, c.ids)
+		}
+	}
+}
+
+func TestFilterByFacilities(t *testing.T) {
+	const pool, wifi, parking = 3, 9, 14
+	list := &HotelList{Hotels: []Hotel{
+		{ID: 1, Facilities: []int{pool, wifi, parking}},
+		{ID: 2, Facilities: []int{wifi}},
+		{ID: 3, Facilities: []int{parking, pool}},
+		{ID: 4},
+		{ID: 5, Facilities: []int{wifi, pool}},
+	}}
+	cases := []struct {
+		required []int
+		ids      []int
+	}{
+		{nil, []int{1, 2, 3, 4, 5}},
+		{[]int{wifi}, []int{1, 2, 5}},
+		{[]int{pool, wifi}, []int{1, 5}},
+		{[]int{wifi, pool, parking}, []int{1}},
+		{[]int{42}, nil},
+	}
+	for _, c := range cases {
+		var ids []int
+		for _, h := range list.FilterByFacilities(c.required) {
+			ids = append(ids, h.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.ids) {
+			t.Fatalf("FilterByFacilities(%v) returns %v, expected %v", c.required, ids, c.ids)
+		}
+	}
+
+	all := list.FilterByFacilities(nil)
+	all[0].ID = 42
+	if list.Hotels[0].ID != 1 {
+		t.Fatal("FilterByFacilities without requirements should return a copy")
+	}
+}
+
+const hotelPhotosFixture = `{
+	"id": 277083,
+	"photoCount": 4,
+	"photos": [
+		{"url": "https://photo.hotellook.com/image_v2/limit/h277083_0/640/480.jpg", "width": 640, "height": 480},
+		{"url": "https://photo.hotellook.com/image_v2/limit/h277083_1/1024/768.jpg", "width": 1024, "height": 768},
+		{"url": "https://photo.hotellook.com/image_v2/limit/h277083_2/1024/768.jpg", "width": 1024, "height": 768},
+		{"url": "https://photo.hotellook.com/image_v2/limit/h277083_3/320/240.jpg", "width": 320, "height": 240}
+	]
+}`
+
+func TestHotelPhotos(t *testing.T) {
+	var h Hotel
+	if err := ffjson.NewDecoder().Decode([]byte(hotelPhotosFixture), &h); err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := "https://photo.hotellook.com/image_v2/limit/h277083_1/640/480.jpg"
+	if url := h.CoverPhotoURL(PhotoMedium); url != expected {
+		t.Fatalf("cover photo is %q, expected the first largest one %q", url, expected)
+	}
+
+	urls := h.PhotoURLs(PhotoSmall, 2)
+	if len(urls) != 2 || urls[1] != "https://photo.hotellook.com/image_v2/limit/h277083_1/240/240.jpg" {
+		t.Fatalf("PhotoURLs with max 2 returns %q", urls)
+	}
+	if urls := h.PhotoURLs(PhotoSmall, 0); len(urls) != 4 {
+		t.Fatalf("PhotoURLs without max returns %d links, expected 4", len(urls))
+	}
+	if urls := h.PhotoURLs(PhotoSmall, 10); len(urls) != 4 {
+		t.Fatalf("PhotoURLs with max over count returns %d links, expected 4", len(urls))
+	}
+
+	var empty Hotel
+	if empty.CoverPhotoURL(PhotoLarge) != "" || empty.PhotoURLs(PhotoLarge, 5) != nil {
+		t.Fatal("hotel without photos should have no links")
+	}
+}
+
+func TestEnrichWithStatic(t *testing.T) {
+	calls := 0
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if r.URL.Query().Get("locationId") != "12153" {
+			t.Fatalf("hotel list requested for location %q", r.URL.Query().Get("locationId"))
+		}
+		return mockResponse(`{"gen_timestamp": 1481371200, "hotels": [
+			{"id": 1, "yearOpened": 1975, "photoCount": 12, "facilities": [3, 9]},
+			{"id": 2, "yearOpened": 2012, "photoCount": 4, "facilities": [14]}
+		]}`), nil
+	})
+	api.SetToken(token)
+	results := &SearchResults{Results: []SearchResult{
+		{ID: 2, PhotoCount: 7},
+		{ID: 3},
+		{ID: 1},
+	}}
+
+	for i := 0; i < 2; i++ {
+		if err := api.EnrichWithStatic(context.Background(), results, "12153"); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("hotel list was fetched %d times, expected once", calls)
+	}
+	r := results.Results
+	if r[0].Static == nil || r[0].Static.YearOpened != 2012 || r[0].PhotoCount != 7 || fmt.Sprint(r[0].Amenities) != "[14]" {
+		t.Fatalf("hotel 2 is enriched as %+v", r[0])
+	}
+	if r[1].Static != nil {
+		t.Fatal("hotel missing in hotel list got static data")
+	}
+	if r[2].Static == nil || r[2].Static.ID != 1 || r[2].PhotoCount != 12 || fmt.Sprint(r[2].Amenities) != "[3 9]" {
+		t.Fatalf("hotel 1 is enriched as %+v", r[2])
+	}
+
+	// Changes of enriched results don't get into cached hotel list.
+	r[2].Static.Facilities[0] = 99
+	r[2].Amenities[1] = 99
+	again := &SearchResults{Results: []SearchResult{{ID: 1}}}
+	if err := api.EnrichWithStatic(context.Background(), again, "12153"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if s := again.Results[0]; fmt.Sprint(s.Static.Facilities, s.Amenities) != "[3 9] [3 9]" {
+		t.Fatalf("got facilities %v and amenities %v, expected cached list unchanged", s.Static.Facilities, s.Amenities)
+	}
+}
+
+func TestEnrichWithStaticCacheLimits(t *testing.T) {
+	calls := make(map[string]int)
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls[r.URL.Query().Get("locationId")]++
+		return mockResponse(`{"hotels": []}`), nil
+	})
+	c := newFakeClock()
+	api.setClock(c)
+	enrich := func(locationID string) {
+		if err := api.EnrichWithStatic(context.Background(), &SearchResults{}, locationID); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	enrich("1")
+	c.now = c.now.Add(hotelListTTL - time.Minute)
+	enrich("1")
+	if calls["1"] != 1 {
+		t.Fatalf("hotel list was fetched %d times before it expired", calls["1"])
+	}
+	c.now = c.now.Add(time.Minute)
+	enrich("1")
+	if calls["1"] != 2 {
+		t.Fatalf("hotel list was fetched %d times, expected expired one to be fetched again", calls["1"])
+	}
+
+	for i := 2; i <= maxHotelLists+1; i++ {
+		c.now = c.now.Add(time.Second)
+		enrich(strconv.Itoa(i))
+	}
+	if len(api.hotelLists) != maxHotelLists {
+		t.Fatalf("got %d hotel lists, expected at most %d", len(api.hotelLists), maxHotelLists)
+	}
+	if _, ok := api.hotelLists["1"]; ok {
+		t.Fatal("the oldest hotel list was not dropped")
+	}
+}
@@ -0,0 +1,39 @@
+package hotellook
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Returns IP of the client which made request to your server, to be passed
+// as CustomerIP. X-Forwarded-For is walked from the right, skipping private
+// and loopback hops of your own proxies, so addresses prepended by the client
+// are ignored; then X-Real-IP and request's remote address are used. Headers
+// can be forged, trust them only behind a proxy which sets them.
+// Returns nil if no address is valid.
+func ClientIPFromRequest(r *http.Request) net.IP {
+	var internal net.IP
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			continue
+		}
+		if !ip.IsPrivate() && !ip.IsLoopback() {
+			return ip
+		}
+		internal = ip
+	}
+	if internal != nil {
+		return internal
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
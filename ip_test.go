@@ -0,0 +1,55 @@
+package hotellook
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestClientIPFromRequest(t *testing.T) {
+	cases := []struct {
+		forwarded, realIP, remote string
+		expected                  string
+	}{
+		{"203.0.113.7, 10.0.0.1", "", "10.0.0.2:4242", "203.0.113.7"},
+		{"unknown, 2001:db8::1", "", "10.0.0.2:4242", "2001:db8::1"},
+		{"192.0.2.1, 203.0.113.7, 10.0.0.1", "", "10.0.0.2:4242", "203.0.113.7"},
+		{"10.1.2.3, 127.0.0.1", "", "10.0.0.2:4242", "10.1.2.3"},
+		{"", "198.51.100.3", "10.0.0.2:4242", "198.51.100.3"},
+		{"", "", "192.0.2.10:4242", "192.0.2.10"},
+		{"", "", "[2001:db8::2]:4242", "2001:db8::2"},
+		{"", "", "pipe", "<nil>"},
+	}
+	for _, c := range cases {
+		r := &http.Request{Header: http.Header{}, RemoteAddr: c.remote}
+		if c.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.realIP != "" {
+			r.Header.Set("X-Real-IP", c.realIP)
+		}
+		if got := ClientIPFromRequest(r).String(); got != c.expected {
+			t.Fatalf("got %s for %+v, expected %s", got, c, c.expected)
+		}
+	}
+}
+
+func TestCustomerIPValidation(t *testing.T) {
+	search := &SearchRequest{CustomerIP: net.IP{1, 2, 3}}
+	if search.Validate() == nil {
+		t.Fatal("malformed customer IP of search should be rejected")
+	}
+	search.CustomerIP = net.ParseIP("203.0.113.7")
+	if err := search.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	price := &PriceRequest{Location: "MOW", CustomerIP: net.IP{1}}
+	if price.Validate() == nil {
+		t.Fatal("malformed customer IP of price should be rejected")
+	}
+	price.CustomerIP = nil
+	if err := price.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
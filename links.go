@@ -0,0 +1,53 @@
+package hotellook
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// Host of relative links in search results (SearchResult.URL, Room.BookingURL).
+const searchHost = "http://search.hotellook.com"
+
+// Parses hotel or booking link of search results. Relative links
+// are resolved against search.hotellook.com.
+func ParseBookingURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, errors.New("Empty booking URL")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.IsAbs() {
+		return u, nil
+	}
+	base, _ := url.Parse(searchHost)
+	return base.ResolveReference(u), nil
+}
+
+// Returns link with params added to its query, existing params with
+// the same names are replaced.
+func WithExtraParams(raw string, params map[string]string) (string, error) {
+	u, err := ParseBookingURL(raw)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	u.RawQuery = encodeQuery(&q)
+	return u.String(), nil
+}
+
+// Returns absolute link of hotel with affiliate marker, existing marker
+// is replaced. Relative links are resolved like in ParseBookingURL.
+// Returns empty string if hotel has no valid link.
+func (this *Hotel) AffiliateLink(marker int) string {
+	link, err := WithExtraParams(this.Link, map[string]string{"marker": strconv.Itoa(marker)})
+	if err != nil {
+		return ""
+	}
+	return link
+}
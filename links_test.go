@@ -0,0 +1,68 @@
+package hotellook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBookingURL(t *testing.T) {
+	u, err := ParseBookingURL("/r/?host=v2%3A62597&roomId=0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if u.Host != "search.hotellook.com" || u.Path != "/r/" || u.Query().Get("host") != "v2:62597" {
+		t.Fatalf("got %s, expected relative link resolved against search host", u)
+	}
+
+	u, err = ParseBookingURL("http://search.hotellook.ru/?hotelId=1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if u.Host != "search.hotellook.ru" {
+		t.Fatalf("host of absolute link changed to %s", u.Host)
+	}
+
+	if _, err = ParseBookingURL(""); err == nil {
+		t.Fatal("empty link should not be parsed")
+	}
+	if _, err = ParseBookingURL("http://%zz"); err == nil {
+		t.Fatal("invalid link should not be parsed")
+	}
+}
+
+func TestWithExtraParams(t *testing.T) {
+	link, err := WithExtraParams("http://search.hotellook.com/?marker=62597&hotelId=1&utm_source=api",
+		map[string]string{"utm_source": "newsletter", "sub_id": "a b"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	u, _ := ParseBookingURL(link)
+	q := u.Query()
+	if q.Get("marker") != "62597" || q.Get("hotelId") != "1" {
+		t.Fatalf("existing params are lost in %s", link)
+	}
+	if q.Get("utm_source") != "newsletter" || q.Get("sub_id") != "a b" || len(q["utm_source"]) != 1 {
+		t.Fatalf("params are not added to %s", link)
+	}
+	if !strings.Contains(link, "sub_id=a%20b") {
+		t.Fatalf("space is not encoded as %%20 in %s", link)
+	}
+}
+
+func TestHotelAffiliateLink(t *testing.T) {
+	cases := []struct {
+		link, expected string
+	}{
+		{"/hotels/moscow/parus-hotel?hotelId=716111", "http://search.hotellook.com/hotels/moscow/parus-hotel?hotelId=716111&marker=35290"},
+		{"https://search.hotellook.com/?hotelId=716111", "https://search.hotellook.com/?hotelId=716111&marker=35290"},
+		{"http://search.hotellook.com/?hotelId=716111&marker=1", "http://search.hotellook.com/?hotelId=716111&marker=35290"},
+		{"", ""},
+		{"http://%zz", ""},
+	}
+	for _, c := range cases {
+		h := Hotel{Link: c.link}
+		if link := h.AffiliateLink(35290); link != c.expected {
+			t.Fatalf("AffiliateLink of %q is %q, expected %q", c.link, link, c.expected)
+		}
+	}
+}
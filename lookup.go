@@ -0,0 +1,144 @@
+package hotellook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Cleans up user input for Lookup: removes control characters and invalid
+// UTF-8, trims spaces and collapses repeated ones. Case and punctuation
+// are kept.
+func NormalizeQuery(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Removes locations and hotels with duplicate IDs, keeping the one with the
+// highest score in place of the first occurrence.
+func (this *LookupResponse) Dedup() {
+	locations := this.Results.Locations[:0]
+	seen := make(map[string]int)
+	for _, l := range this.Results.Locations {
+		if i, ok := seen[l.ID]; ok {
+			if l.Score > locations[i].Score {
+				locations[i] = l
+			}
+			continue
+		}
+		seen[l.ID] = len(locations)
+		locations = append(locations, l)
+	}
+	this.Results.Locations = locations
+
+	hotels := this.Results.Hotels[:0]
+	seen = make(map[string]int)
+	for _, h := range this.Results.Hotels {
+		id := lookupHotelID(h.ID)
+		if i, ok := seen[id]; ok {
+			if h.Score > hotels[i].Score {
+				hotels[i] = h
+			}
+			continue
+		}
+		seen[id] = len(hotels)
+		hotels = append(hotels, h)
+	}
+	this.Results.Hotels = hotels
+}
+
+// Returns hotel with the highest score, if its score is at least minScore.
+// Scores are relative, so threshold should be chosen for the kind of queries.
+func BestHotelMatch(resp *LookupResponse, minScore float64) (*LookupHotel, bool) {
+	var best *LookupHotel
+	for i := range resp.Results.Hotels {
+		if best == nil || resp.Results.Hotels[i].Score > best.Score {
+			best = &resp.Results.Hotels[i]
+		}
+	}
+	if best == nil || best.Score < minScore {
+		return nil, false
+	}
+	return best, true
+}
+
+// Hotel ID may be decoded either as number or as string.
+func lookupHotelID(id interface{}) string {
+	if f, ok := id.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(id)
+}
+
+// Looks up several queries concurrently, using opts as a template for every
+// request. At most concurrency lookups are performed at once, each of them
+// waits for rate limit reset if needed. Returns responses by query and the
+// first error, if any; lookups which are not started yet are cancelled then.
+func (this *API) LookupBatch(ctx context.Context, queries []string, opts LookupRequest, concurrency int) (map[string]*LookupResponse, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+		results  = make(map[string]*LookupResponse, len(queries))
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	for _, q := range queries {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(q string) {
+			defer func() { <-sem; wg.Done() }()
+			if err := this.WaitForRateLimit(ctx); err != nil {
+				fail(err)
+				return
+			}
+			req := opts
+			req.Query = q
+			resp, err := this.lookup(ctx, &req)
+			if err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			results[q] = resp
+			mu.Unlock()
+		}(q)
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return results, firstErr
+}
@@ -0,0 +1,149 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pquerna/ffjson/ffjson"
+)
+
+func TestLookupDedup(t *testing.T) {
+	const body = `{"status":"ok","results":{
+		"locations":[
+			{"id":"12196","cityName":"Saint Petersburg","_score":10},
+			{"id":"12153","cityName":"Moscow","_score":5},
+			{"id":"12196","cityName":"St. Petersburg","_score":20}
+		],
+		"hotels":[
+			{"id":716111,"label":"Parus","_score":3},
+			{"id":"716111","label":"Parus Hotel","_score":7},
+			{"id":716112,"label":"Guru","_score":1},
+			{"id":716112,"label":"Guru Hotel","_score":0.5},
+			{"id":1406958292,"label":"Prestige","_score":1},
+			{"id":"1406958292","label":"Grand Hotel Prestige","_score":2}
+		]}}`
+	resp := new(LookupResponse)
+	if err := ffjson.NewDecoder().Decode([]byte(body), resp); err != nil {
+		t.Fatal(err.Error())
+	}
+	resp.Dedup()
+
+	l := resp.Results.Locations
+	if len(l) != 2 || l[0].CityName != "St. Petersburg" || l[1].CityName != "Moscow" {
+		t.Fatalf("unexpected locations after Dedup: %+v", l)
+	}
+	h := resp.Results.Hotels
+	if len(h) != 3 || h[0].Label != "Parus Hotel" || h[1].Label != "Guru" || h[2].Label != "Grand Hotel Prestige" {
+		t.Fatalf("unexpected hotels after Dedup: %+v", h)
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := map[string]string{
+		"moscow":                  "moscow",
+		"  New   York ":           "New York",
+		"St.\tPetersburg\n":       "St. Petersburg",
+		"Москва\x00\x1b":          "Москва",
+		"Saint-\u00a0Tropez":      "Saint- Tropez",
+		"bad\xffutf8 hotel, Rome": "badutf8 hotel, Rome",
+		" \r\n\t ":                "",
+	}
+	for in, expected := range cases {
+		if out := NormalizeQuery(in); out != expected {
+			t.Fatalf("NormalizeQuery(%q) = %q, expected %q", in, out, expected)
+		}
+	}
+}
+
+func TestLookupNormalize(t *testing.T) {
+	var query url.Values
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return mockResponse(`{"status":"ok"}`), nil
+	})
+	api.Lookup(&LookupRequest{Query: "  New\tYork "})
+	if query.Get("query") != "  New\tYork " {
+		t.Fatalf("query %q is changed without Normalize", query.Get("query"))
+	}
+	api.Lookup(&LookupRequest{Query: "  New\tYork ", Normalize: true})
+	if query.Get("query") != "New York" {
+		t.Fatalf("normalized query is %q", query.Get("query"))
+	}
+}
+
+func TestLookupBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		q := r.URL.Query()
+		if q.Get("lang") != "ru" || q.Get("limit") != "3" {
+			t.Error("options template is not applied: " + r.URL.RawQuery)
+		}
+		if q.Get("query") == "broken" {
+			return nil, errors.New("connection reset")
+		}
+		return mockResponse(`{"status":"ok","results":{"locations":[{"cityName":"` + q.Get("query") + `"}]}}`), nil
+	})
+
+	queries := []string{"moscow", "paris", "rome"}
+	opts := LookupRequest{Lang: "ru", Limit: 3}
+	results, err := api.LookupBatch(context.Background(), queries, opts, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != len(queries) {
+		t.Fatalf("got %d results, expected %d", len(results), len(queries))
+	}
+	for _, q := range queries {
+		if results[q] == nil || results[q].Results.Locations[0].CityName != q {
+			t.Fatalf("wrong response for %q: %+v", q, results[q])
+		}
+	}
+	if maxInFlight > 2 {
+		t.Fatalf("%d lookups were in flight, expected at most 2", maxInFlight)
+	}
+
+	if _, err = api.LookupBatch(context.Background(), []string{"moscow", "broken"}, opts, 1); err == nil {
+		t.Fatal("LookupBatch should return error of failed lookup")
+	}
+}
+
+func TestBestHotelMatch(t *testing.T) {
+	resp := new(LookupResponse)
+	if _, ok := BestHotelMatch(resp, 0); ok {
+		t.Fatal("empty response should have no match")
+	}
+	resp.Results.Hotels = []LookupHotel{
+		{ID: 1.0, Label: "Parus", Score: 12.5},
+		{ID: 2.0, Label: "Parus Hotel", Score: 48.2},
+		{ID: 3.0, Label: "Paris", Score: 3},
+	}
+	cases := []struct {
+		minScore float64
+		ok       bool
+	}{
+		{0, true},
+		{48.2, true},
+		{50, false},
+	}
+	for _, c := range cases {
+		h, ok := BestHotelMatch(resp, c.minScore)
+		if ok != c.ok {
+			t.Fatalf("match with threshold %v: %v, expected %v", c.minScore, ok, c.ok)
+		}
+		if ok && h.Label != "Parus Hotel" {
+			t.Fatalf("got %q, expected hotel with the highest score", h.Label)
+		}
+	}
+}
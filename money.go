@@ -0,0 +1,140 @@
+package hotellook
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currencies without minor units, others have two decimal places.
+var zeroDecimalCurrencies = map[string]bool{
+	"CLP": true, "ISK": true, "JPY": true, "KRW": true, "PYG": true, "UGX": true, "VND": true,
+}
+
+// Amount of money in minor units (e.g. cents), sums of amounts
+// don't drift as sums of floats do.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// Converts price returned by API to Money, rounding it to minor units.
+func NewMoney(price float64, currency string) Money {
+	currency = strings.ToUpper(currency)
+	return Money{
+		Amount:   int64(math.Round(price * math.Pow10(decimals(currency)))),
+		Currency: currency,
+	}
+}
+
+func decimals(currency string) int {
+	if zeroDecimalCurrencies[currency] {
+		return 0
+	}
+	return 2
+}
+
+// Returns sum of amounts, which should be in the same currency.
+func (this Money) Add(other Money) (Money, error) {
+	if this.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: this.Amount + other.Amount, Currency: this.Currency}, nil
+}
+
+// Returns difference of amounts, which should be in the same currency.
+func (this Money) Sub(other Money) (Money, error) {
+	other.Amount = -other.Amount
+	return this.Add(other)
+}
+
+// Returns amount multiplied by n, e.g. price per night by number of nights.
+func (this Money) Mul(n int) Money {
+	return Money{Amount: this.Amount * int64(n), Currency: this.Currency}
+}
+
+// Formats amount with its currency, e.g. "1234.50 USD".
+func (this Money) String() string {
+	sign, s := this.digits()
+	if this.Currency == "" {
+		return sign + s
+	}
+	return sign + s + " " + this.Currency
+}
+
+// Returns sign and absolute amount with decimal point.
+func (this Money) digits() (sign, s string) {
+	d := decimals(this.Currency)
+	amount := this.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s = strconv.FormatInt(amount, 10)
+	if d > 0 {
+		if len(s) <= d {
+			s = strings.Repeat("0", d-len(s)+1) + s
+		}
+		s = s[:len(s)-d] + "." + s[len(s)-d:]
+	}
+	return sign, s
+}
+
+// Symbols of common currencies, written before amount unless suffix is set.
+var currencySymbols = map[string]struct {
+	symbol string
+	suffix bool
+}{
+	"USD": {"$", false},
+	"EUR": {"€", false},
+	"GBP": {"£", false},
+	"JPY": {"¥", false},
+	"CNY": {"¥", false},
+	"INR": {"₹", false},
+	"KRW": {"₩", false},
+	"RUB": {" ₽", true},
+	"UAH": {" ₴", true},
+	"THB": {"฿", false},
+}
+
+// Formats price for display with currency symbol and decimal places of
+// currency, e.g. "$1234.50", "¥1235" or "1234.50 ₽". Currencies without
+// known symbol are formatted like Money.String.
+func FormatPrice(amount float64, currency string) string {
+	m := NewMoney(amount, currency)
+	sym, ok := currencySymbols[m.Currency]
+	if !ok {
+		return m.String()
+	}
+	sign, s := m.digits()
+	if sym.suffix {
+		return sign + s + sym.symbol
+	}
+	return sign + sym.symbol + s
+}
+
+// Lowest price in currency of response.
+func (this *PriceResponse) PriceFromMoney() Money {
+	return NewMoney(this.PriceFrom, this.Currency)
+}
+
+// Average price in currency of response.
+func (this *PriceResponse) PriceAvgMoney() Money {
+	return NewMoney(this.PriceAvg, this.Currency)
+}
+
+// Room price including taxes, see PriceWithTax. Rooms don't carry currency,
+// it's taken from SearchResults.Currency.
+func (this *Room) TotalMoney(currency string) Money {
+	return NewMoney(this.PriceWithTax(), currency)
+}
+
+// Returns room price per guest, e.g. total of Room divided by occupancy of
+// search. Children pay the same share as adults. Returns zero if there are
+// no guests.
+func PricePerGuest(total int, adults, children int) float64 {
+	guests := adults + children
+	if guests <= 0 || adults < 0 || children < 0 {
+		return 0
+	}
+	return float64(total) / float64(guests)
+}
@@ -0,0 +1,97 @@
+package hotellook
+
+import "testing"
+
+func TestMoneyAdd(t *testing.T) {
+	// 0.1 + 0.2 is not 0.3 in floats.
+	sum, err := NewMoney(0.1, "usd").Add(NewMoney(0.2, "USD"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if sum != NewMoney(0.3, "USD") {
+		t.Fatalf("got %v, expected 0.30 USD", sum)
+	}
+
+	var total Money
+	total.Currency = "USD"
+	for i := 0; i < 1000; i++ {
+		total, _ = total.Add(NewMoney(19.99, "USD"))
+	}
+	if total.Amount != 1999000 {
+		t.Fatalf("got %v, expected 19990.00 USD", total)
+	}
+
+	if diff, _ := NewMoney(100, "RUB").Sub(NewMoney(100.5, "RUB")); diff.Amount != -50 {
+		t.Fatalf("got %v, expected -0.50 RUB", diff)
+	}
+	if _, err := NewMoney(1, "USD").Add(NewMoney(1, "EUR")); err != ErrCurrencyMismatch {
+		t.Fatalf("got %v, expected ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	cases := map[string]Money{
+		"1234.50 USD": NewMoney(1234.5, "USD"),
+		"0.05 EUR":    NewMoney(0.05, "EUR"),
+		"-0.50 RUB":   {Amount: -50, Currency: "RUB"},
+		"1235 JPY":    NewMoney(1234.6, "JPY"),
+		"60.00 USD":   NewMoney(20, "USD").Mul(3),
+		"7.00":        NewMoney(7, ""),
+	}
+	for expected, m := range cases {
+		if m.String() != expected {
+			t.Fatalf("got %q, expected %q", m.String(), expected)
+		}
+	}
+
+	p := &PriceResponse{PriceFrom: 99.99, PriceAvg: 120.456, Currency: "USD"}
+	if p.PriceFromMoney().String() != "99.99 USD" || p.PriceAvgMoney().String() != "120.46 USD" {
+		t.Fatalf("got %v and %v", p.PriceFromMoney(), p.PriceAvgMoney())
+	}
+	room := &Room{Price: 100, Tax: 12.5}
+	if room.TotalMoney("usd").String() != "112.50 USD" {
+		t.Fatalf("got %v, expected 112.50 USD", room.TotalMoney("usd"))
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+		expected string
+	}{
+		{1234.5, "USD", "$1234.50"},
+		{1234.5, "jpy", "¥1235"},
+		{0.5, "EUR", "€0.50"},
+		{-19.99, "GBP", "-£19.99"},
+		{6500, "RUB", "6500.00 ₽"},
+		{150000.4, "KRW", "₩150000"},
+		{99.999, "CHF", "100.00 CHF"},
+		{12, "", "12.00"},
+	}
+	for _, c := range cases {
+		if s := FormatPrice(c.amount, c.currency); s != c.expected {
+			t.Fatalf("FormatPrice(%v, %q) = %q, expected %q", c.amount, c.currency, s, c.expected)
+		}
+	}
+}
+
+func TestPricePerGuest(t *testing.T) {
+	cases := []struct {
+		total, adults, children int
+		expected                float64
+	}{
+		{300, 2, 0, 150},
+		{300, 2, 1, 100},
+		{1000, 3, 1, 250},
+		{100, 1, 2, 100.0 / 3},
+		{500, 0, 0, 0},
+		{500, -1, 1, 0},
+		{0, 2, 0, 0},
+	}
+	for _, c := range cases {
+		if p := PricePerGuest(c.total, c.adults, c.children); p != c.expected {
+			t.Fatalf("PricePerGuest(%d, %d, %d) = %v, expected %v", c.total, c.adults, c.children, p, c.expected)
+		}
+	}
+}
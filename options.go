@@ -0,0 +1,63 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Option of a single API call, e.g. WithHeader.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	header http.Header
+}
+
+// Sets header of one call, it overrides header set by SetHeader.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Set(key, value)
+	}
+}
+
+type requestOptionsKey struct{}
+
+// Options are passed down to do through context of the call.
+func withRequestOptions(ctx context.Context, opts []RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	o := new(requestOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, o)
+}
+
+func requestHeader(ctx context.Context) http.Header {
+	if o, ok := ctx.Value(requestOptionsKey{}).(*requestOptions); ok {
+		return o.header
+	}
+	return nil
+}
+
+// Canonical form of header, sorted by key. Calls with different per-call
+// headers must not share coalesced request.
+func headerKey(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range h[k] {
+			b.WriteString("\n" + k + ": " + v)
+		}
+	}
+	return b.String()
+}
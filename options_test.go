@@ -0,0 +1,89 @@
+package hotellook
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithHeader(t *testing.T) {
+	var tenants []string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		return mockResponse(`[]`), nil
+	})
+	api.SetHeader("X-Tenant", "default")
+	req := &PriceRequest{Location: "MOW", CheckIn: "2016-12-10", CheckOut: "2016-12-17"}
+
+	if _, err := api.Price(req, WithHeader("X-Tenant", "acme")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := api.Price(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tenants) != 2 || tenants[0] != "acme" || tenants[1] != "default" {
+		t.Fatalf("sent tenants %q, expected per-call header to override default once", tenants)
+	}
+	if api.header.Get("X-Tenant") != "default" {
+		t.Fatal("per-call header changed default headers")
+	}
+}
+
+func TestWithHeaderCoalescing(t *testing.T) {
+	const n = 6
+	release := make(chan struct{})
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		tenant := r.Header.Get("X-Tenant")
+		mu.Lock()
+		calls[tenant]++
+		mu.Unlock()
+		<-release
+		return mockResponse(`{"status":"ok","results":{"locations":[{"cityName":"` + tenant + `"}]}}`), nil
+	})
+	api.EnableRequestCoalescing()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		tenant := "acme"
+		if i%2 == 1 {
+			tenant = "globex"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := api.Lookup(&LookupRequest{Query: "moscow", Lang: "en"}, WithHeader("X-Tenant", tenant))
+			if err != nil {
+				t.Error(err.Error())
+			} else if got := resp.Results.Locations[0].CityName; got != tenant {
+				t.Errorf("%s got response of %s", tenant, got)
+			}
+		}()
+	}
+
+	// Wait until every caller has joined flight of its tenant.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		api.mu.Lock()
+		dups := 0
+		for _, f := range api.flights {
+			dups += f.dups
+		}
+		flights := len(api.flights)
+		api.mu.Unlock()
+		if flights == 2 && dups == n-2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d flights with %d waiting callers, expected one flight per tenant", flights, dups)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls["acme"] != 1 || calls["globex"] != 1 {
+		t.Fatalf("got calls %v, expected one request per tenant", calls)
+	}
+}
@@ -0,0 +1,90 @@
+package hotellook
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Returns price with the lowest PriceFrom, entries without price are ignored.
+// Returns nil if there are no priced entries.
+func Cheapest(prices []PriceResponse) *PriceResponse {
+	var cheapest *PriceResponse
+	for i := range prices {
+		if prices[i].PriceFrom <= 0 {
+			continue
+		}
+		if cheapest == nil || prices[i].PriceFrom < cheapest.PriceFrom {
+			cheapest = &prices[i]
+		}
+	}
+	return cheapest
+}
+
+// Removes zero entries (without HotelID) from prices in place, keeping
+// order of others. It's a bridge for code which padded or preallocated
+// price slices and can't yet rely on Price returning only decoded entries.
+func TrimZeroResults(resp *[]PriceResponse) {
+	if resp == nil {
+		return
+	}
+	prices := (*resp)[:0]
+	for _, p := range *resp {
+		if p.HotelID != 0 {
+			prices = append(prices, p)
+		}
+	}
+	*resp = prices
+}
+
+// Returns how much prices changed since other, e.g. current.Delta(previous)
+// is positive if hotel became more expensive. Prices of different hotels
+// are not comparable, zeros are returned then.
+func (this *PriceResponse) Delta(other *PriceResponse) (avgDelta, fromDelta float64) {
+	if other == nil || this.HotelID != other.HotelID {
+		return 0, 0
+	}
+	return this.PriceAvg - other.PriceAvg, this.PriceFrom - other.PriceFrom
+}
+
+// Requests prices in several currencies concurrently, base is copied for
+// every currency with only Currency changed. Every request waits for rate
+// limit reset if needed. Returns prices by upper-cased currency and the first
+// error, if any; other requests are cancelled then.
+func (this *API) PriceMulti(ctx context.Context, base PriceRequest, currencies []string) (map[string]*[]PriceResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		results  = make(map[string]*[]PriceResponse, len(currencies))
+	)
+	for _, c := range currencies {
+		wg.Add(1)
+		go func(currency string) {
+			defer wg.Done()
+			err := this.WaitForRateLimit(ctx)
+			var resp *[]PriceResponse
+			if err == nil {
+				req := base
+				req.Currency = currency
+				resp, err = this.price(ctx, &req)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			results[currency] = resp
+		}(strings.ToUpper(c))
+	}
+	wg.Wait()
+
+	return results, firstErr
+}
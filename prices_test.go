@@ -0,0 +1,119 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCheapest(t *testing.T) {
+	if Cheapest(nil) != nil {
+		t.Fatal("Cheapest of empty slice should be nil")
+	}
+	if Cheapest(make([]PriceResponse, 3)) != nil {
+		t.Fatal("Cheapest should ignore entries without price")
+	}
+
+	prices := []PriceResponse{
+		{HotelID: 1, PriceFrom: 120, PriceAvg: 150},
+		{HotelID: 2},
+		{HotelID: 3, PriceFrom: 80.5, PriceAvg: 200},
+		{HotelID: 4, PriceFrom: 95, PriceAvg: 90},
+	}
+	c := Cheapest(prices)
+	if c == nil || c.HotelID != 3 {
+		t.Fatalf("Cheapest returns %+v, expected hotel 3", c)
+	}
+}
+
+func TestTrimZeroResults(t *testing.T) {
+	prices := make([]PriceResponse, 2, 5)
+	prices = append(prices, PriceResponse{HotelID: 3, PriceFrom: 80}, PriceResponse{}, PriceResponse{HotelID: 1})
+	TrimZeroResults(&prices)
+	if len(prices) != 2 || prices[0].HotelID != 3 || prices[1].HotelID != 1 {
+		t.Fatalf("got %+v, expected hotels 3 and 1", prices)
+	}
+
+	empty := make([]PriceResponse, 3)
+	TrimZeroResults(&empty)
+	if len(empty) != 0 {
+		t.Fatalf("got %d entries, expected none", len(empty))
+	}
+	TrimZeroResults(nil)
+}
+
+func TestPriceCurrency(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`[{"hotelId":1,"priceFrom":100},{"hotelId":2,"priceFrom":90}]`), nil
+	})
+	for currency, expected := range map[string]string{"rub": "RUB", "": "USD"} {
+		resp, err := api.Price(&PriceRequest{Location: "MOW", Currency: currency, Limit: 2})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, p := range *resp {
+			if p.Currency != expected {
+				t.Fatalf("price currency is %q, expected %q", p.Currency, expected)
+			}
+		}
+	}
+}
+
+func TestPriceDelta(t *testing.T) {
+	previous := &PriceResponse{HotelID: 716111, PriceAvg: 150, PriceFrom: 120}
+	current := &PriceResponse{HotelID: 716111, PriceAvg: 140.5, PriceFrom: 130}
+
+	avg, from := current.Delta(previous)
+	if avg != -9.5 || from != 10 {
+		t.Fatalf("Delta returns %f, %f, expected -9.5, 10", avg, from)
+	}
+
+	other := &PriceResponse{HotelID: 716112, PriceAvg: 50, PriceFrom: 40}
+	if avg, from = current.Delta(other); avg != 0 || from != 0 {
+		t.Fatal("Delta of different hotels should be zero")
+	}
+	if avg, from = current.Delta(nil); avg != 0 || from != 0 {
+		t.Fatal("Delta with nil should be zero")
+	}
+}
+
+func TestPriceMulti(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = map[string]bool{}
+	)
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		currency := r.URL.Query().Get("currency")
+		mu.Lock()
+		seen[currency] = true
+		mu.Unlock()
+		if currency == "RUB" {
+			return mockResponse(`[{"hotelId":1,"priceFrom":6500}]`), nil
+		}
+		return mockResponse(`[{"hotelId":1,"priceFrom":100}]`), nil
+	})
+	base := PriceRequest{Location: "MOW", CheckIn: "2016-12-10", CheckOut: "2016-12-17", Currency: "eur"}
+	prices, err := api.PriceMulti(context.Background(), base, []string{"usd", "RUB"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(prices) != 2 || len(seen) != 2 || seen["EUR"] {
+		t.Fatalf("got prices in %d currencies, requested %v", len(prices), seen)
+	}
+	if p := (*prices["RUB"])[0]; p.PriceFrom != 6500 || p.Currency != "RUB" {
+		t.Fatalf("RUB price is %+v", p)
+	}
+	if p := (*prices["USD"])[0]; p.PriceFrom != 100 || p.Currency != "USD" {
+		t.Fatalf("USD price is %+v", p)
+	}
+	if base.Currency != "eur" {
+		t.Fatal("PriceMulti modified base request")
+	}
+
+	_, err = api.PriceMulti(context.Background(), PriceRequest{}, []string{"usd"})
+	if !errors.Is(err, ErrMissingParams) {
+		t.Fatalf("got %v, expected ErrMissingParams for invalid request", err)
+	}
+}
@@ -0,0 +1,71 @@
+package hotellook
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Builds request params from struct fields tagged with `query:"name"`.
+// With `query:"name,omitempty"` zero values are skipped. Strings, numbers,
+// bools ("1" or "0") and fmt.Stringer (e.g. net.IP) are supported, other
+// fields and fields without tag are ignored.
+func encodeParams(req interface{}) map[string]string {
+	params := make(map[string]string)
+	v := reflect.Indirect(reflect.ValueOf(req))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("query")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.IndexByte(tag, ','); comma >= 0 {
+			name, opts = tag[:comma], tag[comma+1:]
+		}
+		f := v.Field(i)
+		if opts == "omitempty" && f.IsZero() {
+			continue
+		}
+		if s, ok := formatParam(f); ok {
+			params[name] = s
+		}
+	}
+	return params
+}
+
+func formatParam(f reflect.Value) (string, bool) {
+	if s, ok := f.Interface().(fmt.Stringer); ok {
+		if f.Kind() == reflect.Slice && f.IsNil() {
+			return "", false
+		}
+		return s.String(), true
+	}
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'f', -1, 64), true
+	case reflect.Bool:
+		if f.Bool() {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
+
+// Converts params to url.Values for requests without signature.
+func paramValues(params map[string]string) *url.Values {
+	v := &url.Values{}
+	for k, val := range params {
+		v.Set(k, val)
+	}
+	return v
+}
@@ -0,0 +1,62 @@
+package hotellook
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEncodeParams(t *testing.T) {
+	type request struct {
+		Name     string  `query:"name"`
+		Empty    string  `query:"empty"`
+		Skipped  string  `query:"skipped,omitempty"`
+		Count    int     `query:"count,omitempty"`
+		Zero     int     `query:"zero"`
+		Price    float64 `query:"price"`
+		Flag     bool    `query:"flag,omitempty"`
+		Off      bool    `query:"off"`
+		IP       net.IP  `query:"ip"`
+		NoIP     net.IP  `query:"noIp"`
+		Internal int
+		Ignored  string `query:"-"`
+		Ages     []int  `query:"ages"`
+	}
+	params := encodeParams(&request{
+		Name:     "Saint Petersburg",
+		Count:    3,
+		Price:    99.5,
+		Flag:     true,
+		IP:       net.ParseIP("203.0.113.7"),
+		Internal: 1,
+		Ignored:  "x",
+		Ages:     []int{5},
+	})
+	expected := map[string]string{
+		"name":  "Saint Petersburg",
+		"empty": "",
+		"count": "3",
+		"zero":  "0",
+		"price": "99.5",
+		"flag":  "1",
+		"off":   "0",
+		"ip":    "203.0.113.7",
+	}
+	if len(params) != len(expected) {
+		t.Fatalf("got params %v, expected %v", params, expected)
+	}
+	for k, v := range expected {
+		if got, ok := params[k]; !ok || got != v {
+			t.Fatalf("param %s is %q, expected %q", k, got, v)
+		}
+	}
+}
+
+func TestPriceHotelIDParam(t *testing.T) {
+	params := encodeParams(&PriceRequest{Location: "MOW", HotelID: 716111})
+	if params["hotelId"] != "716111" {
+		t.Fatalf("got params %v, expected hotelId", params)
+	}
+	if _, ok := params["clientIp"]; ok {
+		t.Fatal("empty customer IP should be omitted")
+	}
+}
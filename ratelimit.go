@@ -0,0 +1,182 @@
+package hotellook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Source of time, replaced by tests to avoid sleeping.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (this *API) setClock(c clock) { this.clock = c }
+
+func (this *API) now() time.Time {
+	if this.clock == nil {
+		return realClock{}.Now()
+	}
+	return this.clock.Now()
+}
+
+func (this *API) after(d time.Duration) <-chan time.Time {
+	if this.clock == nil {
+		return realClock{}.After(d)
+	}
+	return this.clock.After(d)
+}
+
+// X-Ratelimit-Reset may contain either number of seconds left until reset
+// or unix timestamp of the reset moment.
+func resetTime(now time.Time, n int64) time.Time {
+	const timestampThreshold = 1e9
+	if n >= timestampThreshold {
+		return time.Unix(n, 0)
+	}
+	return now.Add(time.Duration(n) * time.Second)
+}
+
+// Returns moment when rate limit will be reset (X-Ratelimit-Reset).
+// Zero value means that server didn't report it yet.
+func (this *API) RequestsReset() time.Time {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.reset
+}
+
+// Blocks until rate limit is reset, if there are no remaining requests.
+// Returns immediately if limits are unknown yet or there are requests left.
+func (this *API) WaitForRateLimit(ctx context.Context) error {
+	return this.waitForRateLimit(ctx, 1)
+}
+
+// City list is large, so it's requested only when at least that many
+// requests remain. Otherwise the last request could be spent by a small
+// concurrent call, and the download would fail.
+const citiesMinRemains = 2
+
+// Same as WaitForRateLimit, but waits while less than min requests remain.
+// Requests reserved by ReserveQuota are available only to its context.
+func (this *API) waitForRateLimit(ctx context.Context, min int) error {
+	this.mu.Lock()
+	reserved := this.reserved
+	if res, ok := ctx.Value(reservationKey{}).(*reservation); ok {
+		reserved -= res.left
+	}
+	exhausted := this.limit > 0 && this.remains-reserved < min
+	wait := this.reset.Sub(this.now())
+	this.mu.Unlock()
+	if !exhausted || wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-this.after(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Tells if n more requests fit into current rate limit, and how many
+// requests remain. After reset moment the whole limit is available.
+// Limits are unknown before the first request, then it always reports enough.
+func (this *API) EstimateQuota(n int) (enough bool, remaining int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.limit == 0 {
+		return true, this.remains
+	}
+	remaining = this.remains
+	if !this.reset.IsZero() && !this.now().Before(this.reset) {
+		remaining = this.limit
+	}
+	remaining -= this.reserved
+	return n <= remaining, remaining
+}
+
+// Requests reserved by ReserveQuota, left is guarded by API.mu.
+type reservation struct {
+	left int
+}
+
+type reservationKey struct{}
+
+// Holds back n remaining requests for calls made with returned context,
+// until they are spent or release is called. Other callers of
+// WaitForRateLimit, batch methods and EstimateQuota see quota without them,
+// while calls with returned context may use them without waiting. Plain
+// calls like Lookup don't wait for rate limit at all, so they are not held
+// back. Returns false if less than n requests remain unreserved. Limits are
+// unknown before the first request, then reservation always succeeds.
+func (this *API) ReserveQuota(ctx context.Context, n int) (reserved context.Context, release func(), ok bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.limit > 0 && this.remains-this.reserved < n {
+		return ctx, nil, false
+	}
+	res := &reservation{left: n}
+	this.reserved += n
+	var once sync.Once
+	return context.WithValue(ctx, reservationKey{}, res), func() {
+		once.Do(func() {
+			this.mu.Lock()
+			this.reserved -= res.left
+			res.left = 0
+			this.mu.Unlock()
+		})
+	}, true
+}
+
+// Takes spent request out of reservation of ctx, if there's one.
+func (this *API) spendReservation(ctx context.Context) {
+	res, ok := ctx.Value(reservationKey{}).(*reservation)
+	if !ok {
+		return
+	}
+	this.mu.Lock()
+	if res.left > 0 {
+		res.left--
+		this.reserved--
+	}
+	this.mu.Unlock()
+}
+
+// Snapshot of rate limits and usage counters.
+type RequestStats struct {
+	Remains int
+	Limit   int
+	Reset   time.Time
+	// Number of requests made by this API instance.
+	Total int
+	// Number of requests failed on network level or while reading body.
+	Errors int
+}
+
+func (this *API) Stats() RequestStats {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return RequestStats{
+		Remains: this.remains,
+		Limit:   this.limit,
+		Reset:   this.reset,
+		Total:   this.total,
+		Errors:  this.errors,
+	}
+}
+
+func (this *API) countRequest(err error) {
+	this.mu.Lock()
+	this.total++
+	if err != nil {
+		this.errors++
+	}
+	this.mu.Unlock()
+}
@@ -0,0 +1,299 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Clock which stays at the same moment, After fires only on demand.
+type fakeClock struct {
+	now   time.Time
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:   time.Date(2016, 12, 10, 12, 0, 0, 0, time.UTC),
+		waits: make(chan time.Duration, 10),
+		fire:  make(chan time.Time),
+	}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+// Returns API with exhausted limit which is reset in 30 seconds.
+func exhaustedAPI(c *fakeClock) *API {
+	api := NewAPI(marker)
+	api.setClock(c)
+	r := &http.Response{Header: http.Header{}}
+	r.Header.Set("X-Ratelimit-Remaining", "0")
+	r.Header.Set("X-Ratelimit-Limit", "100")
+	r.Header.Set("X-Ratelimit-Reset", "30")
+	api.updateRemains(r)
+	return api
+}
+
+func TestResetTime(t *testing.T) {
+	now := time.Unix(1481371200, 0)
+	if got := resetTime(now, 30); !got.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("resetTime with seconds returns %v", got)
+	}
+	if got := resetTime(now, 1481371260); !got.Equal(time.Unix(1481371260, 0)) {
+		t.Fatalf("resetTime with timestamp returns %v", got)
+	}
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	c := newFakeClock()
+	api := exhaustedAPI(c)
+
+	done := make(chan error)
+	go func() { done <- api.WaitForRateLimit(context.Background()) }()
+	if d := <-c.waits; d != 30*time.Second {
+		t.Fatalf("WaitForRateLimit waits %v, expected 30s", d)
+	}
+	select {
+	case <-done:
+		t.Fatal("WaitForRateLimit returned before reset")
+	default:
+	}
+	c.fire <- c.now.Add(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- api.WaitForRateLimit(ctx) }()
+	<-c.waits
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("WaitForRateLimit returns %v after cancel, expected context.Canceled", err)
+	}
+
+	if err := NewAPI(marker).WaitForRateLimit(context.Background()); err != nil {
+		t.Fatal("WaitForRateLimit should not wait when limits are unknown")
+	}
+}
+
+func TestStats(t *testing.T) {
+	fail := false
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		resp := mockResponse(`[]`)
+		resp.Header.Set("X-Ratelimit-Remaining", "9")
+		resp.Header.Set("X-Ratelimit-Limit", "10")
+		return resp, nil
+	})
+
+	if _, err := api.Amenities(); err != nil {
+		t.Fatal(err.Error())
+	}
+	s := api.Stats()
+	if s.Total != 1 || s.Errors != 0 || s.Remains != 9 || s.Limit != 10 {
+		t.Fatalf("unexpected stats after first call: %+v", s)
+	}
+
+	fail = true
+	api.Amenities()
+	api.Amenities()
+	if s = api.Stats(); s.Total != 3 || s.Errors != 2 || s.Remains != 9 {
+		t.Fatalf("unexpected stats after failed calls: %+v", s)
+	}
+}
+
+func TestCitiesWaitForRateLimit(t *testing.T) {
+	c := newFakeClock()
+	api := exhaustedAPI(c)
+	api.SetToken(validToken)
+	requested := make(chan bool, 1)
+	api.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested <- true
+		return mockResponse(`[]`), nil
+	})}
+
+	done := make(chan error)
+	go func() {
+		_, err := api.Cities()
+		done <- err
+	}()
+	if d := <-c.waits; d != 30*time.Second {
+		t.Fatalf("Cities waits %v, expected 30s until reset", d)
+	}
+	select {
+	case <-requested:
+		t.Fatal("Cities was requested before reset")
+	default:
+	}
+	c.fire <- c.now.Add(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+	<-requested
+
+	// Single remaining request is not enough for cities, but is for others.
+	r := &http.Response{Header: http.Header{}}
+	r.Header.Set("X-Ratelimit-Remaining", "1")
+	api.updateRemains(r)
+	if err := api.WaitForRateLimit(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- api.CitiesStream(ctx, func(Cities) bool { return true }) }()
+	<-c.waits
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("CitiesStream returns %v after cancel, expected context.Canceled", err)
+	}
+}
+
+func TestRateLimitAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		// Response comes when caller has already cancelled request.
+		cancel()
+		resp := mockResponse(`{"results": {}}`)
+		resp.Header.Set("X-Ratelimit-Remaining", "0")
+		resp.Header.Set("X-Ratelimit-Limit", "100")
+		resp.Header.Set("X-Ratelimit-Reset", "30")
+		return resp, nil
+	})
+	api.setClock(newFakeClock())
+	observed := 0
+	api.SetMetrics(func(ctx context.Context, endpoint string, status int, d time.Duration) {
+		observed++
+		if status != 0 {
+			t.Fatalf("request is reported with status %d after cancel", status)
+		}
+	})
+
+	if _, err := api.lookup(ctx, &LookupRequest{Query: "moscow"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, expected context.Canceled", err)
+	}
+	stats := api.Stats()
+	if stats.Limit != 100 || stats.Remains != 0 {
+		t.Fatalf("got rate limit state %+v, expected headers of dropped response", stats)
+	}
+	// Waiting for reset is bound to context, which is done already.
+	if err := api.WaitForRateLimit(ctx); err != context.Canceled {
+		t.Fatalf("got %v, expected context.Canceled", err)
+	}
+	if observed != 1 || stats.Errors != 1 {
+		t.Fatalf("got %d reports and %d errors, expected one failed request", observed, stats.Errors)
+	}
+}
+
+func TestEstimateQuota(t *testing.T) {
+	c := newFakeClock()
+	api := exhaustedAPI(c)
+	api.remains = 10
+
+	for _, tc := range []struct {
+		n      int
+		enough bool
+	}{{5, true}, {10, true}, {11, false}} {
+		enough, remaining := api.EstimateQuota(tc.n)
+		if enough != tc.enough || remaining != 10 {
+			t.Fatalf("EstimateQuota(%d) = %v, %d; expected %v, 10", tc.n, enough, remaining, tc.enough)
+		}
+	}
+
+	c.now = c.now.Add(time.Minute)
+	if enough, remaining := api.EstimateQuota(50); !enough || remaining != 100 {
+		t.Fatalf("EstimateQuota after reset = %v, %d; expected true, 100", enough, remaining)
+	}
+
+	if enough, _ := NewAPI(1).EstimateQuota(1000); !enough {
+		t.Fatal("unknown limits should not defer requests")
+	}
+}
+
+func TestReserveQuota(t *testing.T) {
+	c := newFakeClock()
+	var mu sync.Mutex
+	remains := 10
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		remains--
+		resp := mockResponse(`{"results": {}}`)
+		resp.Header.Set("X-Ratelimit-Remaining", strconv.Itoa(remains))
+		mu.Unlock()
+		resp.Header.Set("X-Ratelimit-Limit", "100")
+		resp.Header.Set("X-Ratelimit-Reset", "30")
+		return resp, nil
+	})
+	api.setClock(c)
+	api.limit, api.remains, api.reset = 100, 10, c.now.Add(30*time.Second)
+
+	batch, release, ok := api.ReserveQuota(context.Background(), 8)
+	if !ok {
+		t.Fatal("8 of 10 remaining requests should be reserved")
+	}
+	if _, _, ok := api.ReserveQuota(context.Background(), 5); ok {
+		t.Fatal("only 2 unreserved requests remain, reservation of 5 should fail")
+	}
+
+	// Requests of batch are taken out of its reservation.
+	for i := 0; i < 2; i++ {
+		if _, err := api.lookup(batch, &LookupRequest{Query: "moscow"}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if _, remaining := api.EstimateQuota(1); remaining != 2 {
+		t.Fatalf("EstimateQuota reports %d remaining after batch requests, expected 2 unreserved", remaining)
+	}
+	for i := 0; i < 2; i++ {
+		if err := api.WaitForRateLimit(context.Background()); err != nil {
+			t.Fatal(err.Error())
+		}
+		api.lookup(context.Background(), &LookupRequest{Query: "spb"})
+	}
+
+	// Others spent their share and must wait, batch still has 6 requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- api.WaitForRateLimit(ctx)
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		<-c.waits
+	}
+	cancel()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != context.Canceled {
+			t.Fatalf("got %v, expected others to wait for reset", err)
+		}
+	}
+	queries := []string{"a", "b", "c", "d", "e", "f"}
+	if _, err := api.LookupBatch(batch, queries, LookupRequest{}, 3); err != nil {
+		t.Fatalf("batch got %v, expected reserved requests to be available", err)
+	}
+	if api.reserved != 0 || api.remains != 0 {
+		t.Fatalf("got %d reserved of %d remaining, expected batch to spend reservation", api.reserved, api.remains)
+	}
+
+	release()
+	release()
+	if api.reserved != 0 {
+		t.Fatalf("release changed reservation to %d", api.reserved)
+	}
+}
@@ -0,0 +1,30 @@
+package hotellook
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Same as Lookup, but returns response body as is, without decoding.
+// Body is only checked to be a valid JSON, so it can be proxied further.
+func (this *API) LookupRaw(ctx context.Context, req *LookupRequest) ([]byte, error) {
+	body, err := this.lookupBody(ctx, req)
+	return validJSON(EndpointLookup, body, err)
+}
+
+// Same as Price, but returns response body as is, see LookupRaw.
+// Stars filter is not applied to it.
+func (this *API) PriceRaw(ctx context.Context, req *PriceRequest) ([]byte, error) {
+	body, err := this.priceBody(ctx, req)
+	return validJSON(EndpointPrice, body, err)
+}
+
+func validJSON(endpoint string, body []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, wrapErr(endpoint, ErrInvalidJSON)
+	}
+	return body, nil
+}
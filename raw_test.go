@@ -0,0 +1,37 @@
+package hotellook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLookupRaw(t *testing.T) {
+	body := `{"status":"ok","results":{"locations":[{"id":"12153","cityName":"Moscow"}]}}`
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(body), nil
+	})
+	raw, err := api.LookupRaw(context.Background(), &LookupRequest{Query: "moscow"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(raw, []byte(body)) {
+		t.Fatal("LookupRaw returns modified body " + string(raw))
+	}
+
+	body = `[{"hotelId":1,"priceFrom":100}]`
+	raw, err = api.PriceRaw(context.Background(), &PriceRequest{Location: "MOW"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(raw, []byte(body)) {
+		t.Fatal("PriceRaw returns modified body " + string(raw))
+	}
+
+	body = `<html>Bad Gateway</html>`
+	if _, err = api.LookupRaw(context.Background(), &LookupRequest{Query: "moscow"}); !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("LookupRaw returns %v on HTML body, expected ErrInvalidJSON", err)
+	}
+}
@@ -0,0 +1,142 @@
+package hotellook
+
+import (
+	"context"
+	"strings"
+)
+
+// Returns country by its ISO code (case-insensitive) or ErrNotFound.
+// Country list is fetched on first call and kept in memory.
+func (this *API) CountryByCode(ctx context.Context, code string) (*Countries, error) {
+	this.mu.Lock()
+	byCode := this.countries
+	this.mu.Unlock()
+
+	if byCode == nil {
+		if err := this.checkAccess(); err != nil {
+			return nil, err
+		}
+		var list []Countries
+		if err := this.fetchStatic(ctx, StaticCountries, &list); err != nil {
+			return nil, err
+		}
+		byCode = make(map[string]*Countries, len(list))
+		for i := range list {
+			byCode[strings.ToUpper(list[i].Code)] = &list[i]
+		}
+		this.mu.Lock()
+		this.countries = byCode
+		this.mu.Unlock()
+	}
+
+	if c, ok := byCode[strings.ToUpper(code)]; ok {
+		return c, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Index of city IDs and IATA codes in both directions.
+type cityCodes struct {
+	iataByID map[string]string
+	idByIATA map[string]string
+}
+
+func (this *API) loadCityCodes(ctx context.Context) (*cityCodes, error) {
+	this.mu.Lock()
+	codes := this.cityCodes
+	this.mu.Unlock()
+	if codes != nil {
+		return codes, nil
+	}
+
+	if err := this.checkAccess(); err != nil {
+		return nil, err
+	}
+	var list []Cities
+	if err := this.fetchStatic(ctx, StaticCities, &list); err != nil {
+		return nil, err
+	}
+	codes = &cityCodes{
+		iataByID: make(map[string]string, len(list)),
+		idByIATA: make(map[string]string, len(list)),
+	}
+	for _, c := range list {
+		if c.Code == "" {
+			continue
+		}
+		code := strings.ToUpper(c.Code)
+		codes.iataByID[c.ID] = code
+		codes.idByIATA[code] = c.ID
+	}
+	this.mu.Lock()
+	this.cityCodes = codes
+	this.mu.Unlock()
+	return codes, nil
+}
+
+// Returns IATA code of city by its location ID, or ErrNotFound if city is
+// unknown or has no code. City list is fetched on first call and kept in memory.
+func (this *API) CityIDToIATA(ctx context.Context, cityID string) (string, error) {
+	codes, err := this.loadCityCodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	if code, ok := codes.iataByID[cityID]; ok {
+		return code, nil
+	}
+	return "", ErrNotFound
+}
+
+// Returns location ID of city by its IATA code (case-insensitive),
+// or ErrNotFound. See CityIDToIATA.
+func (this *API) IATAToCityID(ctx context.Context, iata string) (string, error) {
+	codes, err := this.loadCityCodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := codes.idByIATA[strings.ToUpper(iata)]; ok {
+		return id, nil
+	}
+	return "", ErrNotFound
+}
+
+// Amenities with the same Amenity.GroupName.
+type AmenityGroup struct {
+	Name      string
+	Amenities []Amenity
+}
+
+// Returns amenities grouped for a filter UI. Groups and amenities within them
+// keep the order of API response. Amenities are fetched on first call and
+// kept in memory, returned slice should not be modified.
+func (this *API) AmenityFilterTree(ctx context.Context) ([]AmenityGroup, error) {
+	this.mu.Lock()
+	groups := this.amenityGroups
+	this.mu.Unlock()
+	if groups != nil {
+		return groups, nil
+	}
+
+	if err := this.checkAccess(); err != nil {
+		return nil, err
+	}
+	var list []Amenity
+	if err := this.fetchStatic(ctx, StaticAmenities, &list); err != nil {
+		return nil, err
+	}
+	groups = make([]AmenityGroup, 0)
+	index := make(map[string]int)
+	for _, a := range list {
+		i, ok := index[a.GroupName]
+		if !ok {
+			i = len(groups)
+			index[a.GroupName] = i
+			groups = append(groups, AmenityGroup{Name: a.GroupName})
+		}
+		groups[i].Amenities = append(groups[i].Amenities, a)
+	}
+	this.mu.Lock()
+	this.amenityGroups = groups
+	this.mu.Unlock()
+	return groups, nil
+}
@@ -0,0 +1,109 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCountryByCode(t *testing.T) {
+	calls := 0
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(`[
+			{"id":"1","code":"RU","EN":[{"name":"Russia"}]},
+			{"id":"2","code":"FR","EN":[{"name":"France"}]}
+		]`), nil
+	})
+
+	c, err := api.CountryByCode(context.Background(), "fr")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c.ID != "2" || c.EN[0].Name != "France" {
+		t.Fatalf("unexpected country %+v", c)
+	}
+	if c, err = api.CountryByCode(context.Background(), "RU"); err != nil || c.ID != "1" {
+		t.Fatalf("got %+v, %v, expected Russia", c, err)
+	}
+	if _, err = api.CountryByCode(context.Background(), "XX"); err != ErrNotFound {
+		t.Fatalf("unknown code returns %v, expected ErrNotFound", err)
+	}
+	if calls != 1 {
+		t.Fatalf("countries were fetched %d times, expected once", calls)
+	}
+}
+
+func TestCityCodes(t *testing.T) {
+	calls := 0
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(`[
+			{"id":"12153","code":"MOW","countryId":"186"},
+			{"id":"12196","code":"LED","countryId":"186"},
+			{"id":"20000","code":"","countryId":"186"}
+		]`), nil
+	})
+	ctx := context.Background()
+
+	if code, err := api.CityIDToIATA(ctx, "12196"); err != nil || code != "LED" {
+		t.Fatalf("CityIDToIATA returns %q, %v; expected LED", code, err)
+	}
+	if id, err := api.IATAToCityID(ctx, "mow"); err != nil || id != "12153" {
+		t.Fatalf("IATAToCityID returns %q, %v; expected 12153", id, err)
+	}
+	if _, err := api.CityIDToIATA(ctx, "20000"); err != ErrNotFound {
+		t.Fatalf("city without code returns %v, expected ErrNotFound", err)
+	}
+	if _, err := api.IATAToCityID(ctx, "XXX"); err != ErrNotFound {
+		t.Fatalf("unknown code returns %v, expected ErrNotFound", err)
+	}
+	if calls != 1 {
+		t.Fatalf("cities were fetched %d times, expected once", calls)
+	}
+}
+
+func TestAmenityFilterTree(t *testing.T) {
+	calls := 0
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(`[
+			{"id":"1","name":"Restaurant","groupName":"Hotel"},
+			{"id":"2","name":"Free Wi-Fi","groupName":"Room"},
+			{"id":"3","name":"Pool","groupName":"Hotel"},
+			{"id":"4","name":"Air conditioning","groupName":"Room"},
+			{"id":"5","name":"Pets allowed","groupName":"Policy"}
+		]`), nil
+	})
+
+	groups, err := api.AmenityFilterTree(context.Background())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := []struct {
+		name string
+		ids  []string
+	}{
+		{"Hotel", []string{"1", "3"}},
+		{"Room", []string{"2", "4"}},
+		{"Policy", []string{"5"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("got %d groups, expected %d", len(groups), len(expected))
+	}
+	for i, e := range expected {
+		g := groups[i]
+		if g.Name != e.name || len(g.Amenities) != len(e.ids) {
+			t.Fatalf("group %d is %s with %d amenities, expected %s with %d", i, g.Name, len(g.Amenities), e.name, len(e.ids))
+		}
+		for j, id := range e.ids {
+			if g.Amenities[j].ID != id {
+				t.Fatalf("amenity %d of %s is %s, expected %s", j, g.Name, g.Amenities[j].ID, id)
+			}
+		}
+	}
+
+	if _, err = api.AmenityFilterTree(context.Background()); err != nil || calls != 1 {
+		t.Fatalf("amenities were fetched %d times (%v), expected once", calls, err)
+	}
+}
@@ -0,0 +1,430 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Page size used by FetchAllSearchResults when request has no limit.
+const defaultPageSize = 100
+
+// FetchAllSearchResults keeps at most that many hotels in memory and sets
+// SearchResults.Truncated if there were more. Zero means no limit.
+var MaxAccumulatedResults = 5000
+
+// Reports whether search found nothing.
+func (this *SearchResults) Empty() bool { return len(this.Results) == 0 }
+
+// Returns number of found hotels.
+func (this *SearchResults) Len() int { return len(this.Results) }
+
+// Returns number of room offers of all found hotels.
+func (this *SearchResults) RoomCount() int {
+	n := 0
+	for _, h := range this.Results {
+		n += len(h.Rooms)
+	}
+	return n
+}
+
+// Returns found hotel by its ID.
+func (this *SearchResults) HotelByID(id int) (*SearchResult, bool) {
+	for i := range this.Results {
+		if this.Results[i].ID == id {
+			return &this.Results[i], true
+		}
+	}
+	return nil, false
+}
+
+// Returns results with one entry per hotel ID, the one with the lowest
+// Price, in order of first occurrence. Hotel may be repeated on several
+// pages of search results when they shift between requests.
+func DedupResults(results []SearchResult) []SearchResult {
+	var deduped []SearchResult
+	index := make(map[int]int, len(results))
+	for _, h := range results {
+		deduped, _ = addResult(deduped, index, h)
+	}
+	return deduped
+}
+
+// Appends h to results unless hotel is already there, cheaper entry
+// replaces the existing one. Index holds positions of hotels by ID.
+func addResult(results []SearchResult, index map[int]int, h SearchResult) ([]SearchResult, bool) {
+	if i, ok := index[h.ID]; ok {
+		if cheaper(h.Price, results[i].Price) {
+			results[i] = h
+		}
+		return results, false
+	}
+	index[h.ID] = len(results)
+	return append(results, h), true
+}
+
+// Unknown (zero) price is never cheaper.
+func cheaper(price, than int) bool {
+	return price > 0 && (than <= 0 || price < than)
+}
+
+// Fetches search results page by page, starting from req.Offset, and merges
+// them into one SearchResults. Hotels repeated on several pages are returned
+// once, see DedupResults. Stops after MaxAccumulatedResults hotels, filters
+// like Stars are applied to merged hotels after that.
+func (this *API) FetchAllSearchResults(ctx context.Context, req *SearchResultsRequest) (*SearchResults, error) {
+	all, err := this.fetchAllSearchResults(ctx, req)
+	if err == nil {
+		req.filter(all)
+	}
+	return all, err
+}
+
+func (this *API) fetchAllSearchResults(ctx context.Context, req *SearchResultsRequest) (*SearchResults, error) {
+	page := *req
+	if page.Limit <= 0 {
+		page.Limit = defaultPageSize
+	}
+
+	all := new(SearchResults)
+	index := make(map[int]int)
+	for {
+		resp, err := this.fetchSearchResultsPage(ctx, &page)
+		if err != nil {
+			return nil, err
+		}
+		all.Status = resp.Status
+
+		added := 0
+		for _, h := range resp.Results {
+			var ok bool
+			if all.Results, ok = addResult(all.Results, index, h); ok {
+				added++
+			}
+		}
+		if max := MaxAccumulatedResults; max > 0 && len(all.Results) >= max {
+			all.Truncated = len(all.Results) > max || len(resp.Results) == page.Limit
+			all.Results = all.Results[:max]
+			return all, nil
+		}
+		// Last page is not full, and page without new hotels means
+		// that server ignores offset.
+		if len(resp.Results) < page.Limit || added == 0 {
+			return all, nil
+		}
+		page.Offset += page.Limit
+	}
+}
+
+// Page of search results for REST services built on top of API.
+type SearchResultsPage struct {
+	Items []SearchResult `json:"items"`
+	// Offset of the next page, zero if it's the last one.
+	NextOffset int `json:"nextOffset,omitempty"`
+	// Number of hotels up to the end of this page. API doesn't report
+	// the total count, so it's exact only on the last page.
+	Total int `json:"total"`
+}
+
+// Fetches one page of search results, req.Limit hotels from req.Offset,
+// or defaultPageSize hotels if limit is not set. Page is considered the last
+// one when it has less hotels than requested. Filters like Stars are applied
+// to the page afterwards, so Total and NextOffset count hotels before them.
+func (this *API) PageSearchResults(ctx context.Context, req *SearchResultsRequest) (*SearchResultsPage, error) {
+	page := *req
+	if page.Limit <= 0 {
+		page.Limit = defaultPageSize
+	}
+	resp, err := this.fetchSearchResultsPage(ctx, &page)
+	if err != nil {
+		return nil, err
+	}
+	n := len(resp.Results)
+	req.filter(resp)
+
+	p := &SearchResultsPage{
+		Items: resp.Results,
+		Total: page.Offset + n,
+	}
+	if p.Items == nil {
+		p.Items = []SearchResult{}
+	}
+	if n >= page.Limit {
+		p.NextOffset = p.Total
+	}
+	return p, nil
+}
+
+// Applies filters of request which API doesn't support to fetched results.
+func (this *SearchResultsRequest) filter(resp *SearchResults) {
+	byRoom := this.Breakfast || this.Refundable || this.FreeWifi
+	if this.Stars == 0 && !byRoom {
+		return
+	}
+	kept := resp.Results[:0]
+	for _, h := range resp.Results {
+		if this.Stars != 0 && h.Stars != this.Stars {
+			continue
+		}
+		if byRoom {
+			var rooms []Room
+			for _, room := range h.Rooms {
+				if this.roomMatches(&room) {
+					rooms = append(rooms, room)
+				}
+			}
+			if len(rooms) == 0 {
+				continue
+			}
+			h.Rooms = rooms
+		}
+		kept = append(kept, h)
+	}
+	resp.Results = kept
+}
+
+func (this *SearchResultsRequest) roomMatches(room *Room) bool {
+	o := &room.Options
+	return (!this.Breakfast || o.Breakfast) && (!this.Refundable || o.Refundable) && (!this.FreeWifi || o.FreeWifi)
+}
+
+// Error code of getResult response while search is in progress.
+const searchNotFinished = 4
+
+// Options of WaitForSearchResults.
+type WaitOptions struct {
+	// Delay between polls, 2 seconds by default.
+	Interval time.Duration
+	// Called after every poll with status of search, partial is nil
+	// while API reports that search is not finished.
+	OnProgress func(status string, partial *SearchResults)
+}
+
+// Polls search results until search is finished, opts may be nil.
+// Use ctx to limit waiting time. Numbers of found hotels and room offers
+// are reported by Len and RoomCount of results.
+func (this *API) WaitForSearchResults(ctx context.Context, req *SearchResultsRequest, opts *WaitOptions) (*SearchResults, error) {
+	interval := 2 * time.Second
+	var progress func(string, *SearchResults)
+	if opts != nil {
+		if opts.Interval > 0 {
+			interval = opts.Interval
+		}
+		progress = opts.OnProgress
+	}
+
+	for {
+		resp, err := this.fetchSearchResults(ctx, req)
+		var e *APIError
+		if err != nil && !(errors.As(err, &e) && e.Code == searchNotFinished) {
+			return nil, err
+		}
+		if progress != nil {
+			if err != nil {
+				progress(e.Message, nil)
+			} else {
+				progress(resp.Status, resp)
+			}
+		}
+		if err == nil {
+			return resp, nil
+		}
+
+		select {
+		case <-this.after(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// API returns total room price for the whole stay in Total, which usually
+// includes Tax, and price without taxes in Price. Some agencies don't fill
+// Total, and some send Total equal to Price with non-zero Tax, meaning that
+// Total excludes tax. In both cases it's calculated as Price + Tax.
+func (this *Room) PriceWithTax() float64 {
+	if this.Total > 0 && !(this.Total == this.Price && this.Tax > 0) {
+		return float64(this.Total)
+	}
+	return float64(this.Price) + this.Tax
+}
+
+// Room price without taxes, see PriceWithTax. If agency didn't fill Price,
+// it's calculated as Total - Tax.
+func (this *Room) PriceWithoutTax() float64 {
+	if this.Price == 0 && this.Total > 0 {
+		return float64(this.Total) - this.Tax
+	}
+	return float64(this.Price)
+}
+
+// Returns average guest score of found hotels, hotels without score
+// are skipped. Returns 0 if no hotel has score.
+func (this *SearchResults) AverageGuestScore() float64 {
+	var sum, n int
+	for _, h := range this.Results {
+		if h.GuestScore > 0 {
+			sum += h.GuestScore
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(sum) / float64(n)
+}
+
+// Returns median of hotel prices (SearchResult.Price), hotels without
+// price are skipped. Returns 0 if no hotel has price.
+func (this *SearchResults) MedianPrice() float64 {
+	var prices []int
+	for _, h := range this.Results {
+		if h.Price > 0 {
+			prices = append(prices, h.Price)
+		}
+	}
+	if len(prices) == 0 {
+		return 0
+	}
+	sort.Ints(prices)
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return float64(prices[mid-1]+prices[mid]) / 2
+	}
+	return float64(prices[mid])
+}
+
+// Sorts hotels by total price of the stay, cheapest first. Hotels without
+// price go last, order of hotels with equal prices is kept.
+func (this *SearchResults) SortByMinTotal() {
+	this.sortByPrice(func(h *SearchResult) float64 { return float64(h.MinPriceTotal) })
+}
+
+// Sorts hotels by maximal price per night, see SortByMinTotal.
+func (this *SearchResults) SortByMaxPricePerNight() {
+	this.sortByPrice(func(h *SearchResult) float64 { return float64(h.MaxPricePerNight) })
+}
+
+// Sorts hotels by key ascending, hotels with zero or negative key go last.
+func (this *SearchResults) sortByPrice(key func(*SearchResult) float64) {
+	sort.SliceStable(this.Results, func(i, j int) bool {
+		a, b := key(&this.Results[i]), key(&this.Results[j])
+		if a <= 0 || b <= 0 {
+			return b <= 0 && a > 0
+		}
+		return a < b
+	})
+}
+
+// Sorts hotels by price per star, best value first. Hotels without stars
+// are rated by guest score (0-100) converted to five-star scale. Hotels
+// without price or rating go last, order of equal values is kept.
+func (this *SearchResults) SortByValue() {
+	value := func(h *SearchResult) float64 {
+		quality := float64(h.Stars)
+		if quality <= 0 {
+			quality = float64(h.GuestScore) / 20
+		}
+		if h.Price <= 0 || quality <= 0 {
+			return 0
+		}
+		return float64(h.Price) / quality
+	}
+	this.sortByPrice(value)
+}
+
+// Returns CDN links of at most max photos of found hotel, built from its
+// PhotoCount like api.PhotoLink does, all of them if max is zero.
+// Links don't depend on settings of api.
+func (this *SearchResult) PhotoURLs(api *API, size PhotoSize, max int) []string {
+	return photoURLs(this.ID, this.PhotoCount, size, max)
+}
+
+// Counts room offers of all found hotels by agency ID.
+func (this *SearchResults) Agencies() map[string]int {
+	agencies := make(map[string]int)
+	for _, h := range this.Results {
+		for _, room := range h.Rooms {
+			agencies[room.AgencyID]++
+		}
+	}
+	return agencies
+}
+
+// Returns room offers of the agency in all found hotels.
+func (this *SearchResults) RoomsByAgency(id string) []Room {
+	var rooms []Room
+	for _, h := range this.Results {
+		for _, room := range h.Rooms {
+			if room.AgencyID == id {
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	return rooms
+}
+
+// Polls search results like WaitForSearchResults, but at most for maxWait.
+// If search is not finished by then, empty results with Partial set are
+// returned instead of error. Hotels found so far are not kept, since every
+// poll before the last one fails with "search is not finished".
+func (this *API) FetchSearchResultsTimeout(ctx context.Context, req *SearchResultsRequest, maxWait time.Duration) (*SearchResults, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	resp, err := this.WaitForSearchResults(waitCtx, req, nil)
+	// Only own deadline is turned into partial results, not the caller's one.
+	if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+		return &SearchResults{Partial: true}, nil
+	}
+	return resp, err
+}
+
+// Replaces host of absolute links with affiliate host.
+func (this *API) rewriteLinks(resp *SearchResults) {
+	this.mu.Lock()
+	host := this.affiliateHost
+	this.mu.Unlock()
+	if host == "" {
+		return
+	}
+	for i := range resp.Results {
+		h := &resp.Results[i]
+		h.FullURL = withHost(h.FullURL, host)
+		for j := range h.Rooms {
+			h.Rooms[j].FullBookingURL = withHost(h.Rooms[j].FullBookingURL, host)
+		}
+	}
+}
+
+// Returns raw URL with replaced host, relative and invalid URLs are kept.
+func withHost(raw, host string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Host = host
+	return u.String()
+}
+
+// Sets Currency from the first booking link with currency param.
+func (this *SearchResults) fillCurrency() {
+	if this.Currency != "" {
+		return
+	}
+	for _, h := range this.Results {
+		for _, room := range h.Rooms {
+			u, err := url.Parse(room.FullBookingURL)
+			if err != nil {
+				continue
+			}
+			if c := u.Query().Get("currency"); c != "" {
+				this.Currency = strings.ToUpper(c)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package hotellook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/pquerna/ffjson/ffjson"
+)
+
+// Loads saved getResult response, see test_data.json.
+func loadSearchResults(t *testing.T) *SearchResults {
+	f, err := os.Open("./test_data.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+	resp, err := DecodeSearchResults(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return resp
+}
+
+func TestSearchResultsLen(t *testing.T) {
+	empty := new(SearchResults)
+	if !empty.Empty() || empty.Len() != 0 {
+		t.Fatal("SearchResults without results should be empty")
+	}
+	if _, ok := empty.HotelByID(716111); ok {
+		t.Fatal("HotelByID found hotel in empty results")
+	}
+
+	if empty.RoomCount() != 0 {
+		t.Fatal("SearchResults without results should have no rooms")
+	}
+
+	resp := loadSearchResults(t)
+	if resp.Empty() || resp.Len() != 28 {
+		t.Fatalf("got Len()=%d, expected 28", resp.Len())
+	}
+	h, ok := resp.HotelByID(716111)
+	if !ok || h.Name != "Parus Hotel" {
+		t.Fatal("HotelByID didn't find Parus Hotel")
+	}
+	if _, ok := resp.HotelByID(1); ok {
+		t.Fatal("HotelByID found hotel with unknown ID")
+	}
+}
+
+func TestSearchResultsCurrency(t *testing.T) {
+	resp := loadSearchResults(t)
+	if resp.Currency != "USD" {
+		t.Fatalf("currency is %q, expected USD from booking links", resp.Currency)
+	}
+
+	empty := new(SearchResults)
+	empty.fillCurrency()
+	if empty.Currency != "" {
+		t.Fatal("empty results should have no currency")
+	}
+}
+
+func TestSearchResultsDecode(t *testing.T) {
+	h, _ := loadSearchResults(t).HotelByID(716111)
+	if h.Rating != 0 || len(h.Amenities) != 16 || h.PhotosByRoomType["9"] != 32 {
+		t.Fatalf("hotel fields are not decoded: %+v", h)
+	}
+	room := h.Rooms[0]
+	if room.InternalTypeID != "4" || room.Total != 194 || room.AgencyName != "ZenHotels.com" {
+		t.Fatalf("room fields are not decoded: %+v", room)
+	}
+	o := room.Options
+	if !o.Refundable || !o.CardRequired || !o.Breakfast || o.Deposit || o.Bedrooms != 1 || o.Beds["single"] != 2 {
+		t.Fatalf("room options are not decoded: %+v", o)
+	}
+}
+
+// Returns API serving getResult pages of hotels with given IDs, paginated
+// by limit and offset params. Every request is sent to pages channel.
+func pagedMockAPI(ids []int, pages chan<- string) *API {
+	return mockAPI(func(r *http.Request) (*http.Response, error) {
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		if pages != nil {
+			pages <- q.Get("offset")
+		}
+		var results []string
+		for i := offset; i < len(ids) && i < offset+limit; i++ {
+			results = append(results, fmt.Sprintf(`{"id":%d,"price":%d}`, ids[i], 100+i))
+		}
+		return mockResponse(`{"status":"ok","result":[` + strings.Join(results, ",") + `]}`), nil
+	})
+}
+
+func TestFetchAllSearchResults(t *testing.T) {
+	pages := make(chan string, 10)
+	// Hotel 3 is shifted to the next page.
+	api := pagedMockAPI([]int{1, 2, 3, 3, 4}, pages)
+	resp, err := api.FetchAllSearchResults(context.Background(), &SearchResultsRequest{
+		SearchID: 42,
+		Limit:    2,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	close(pages)
+	if len(pages) != 3 {
+		t.Fatalf("got %d requests, expected 3 pages", len(pages))
+	}
+	if resp.Len() != 4 || resp.Status != "ok" {
+		t.Fatalf("got %d hotels, expected 4 unique", resp.Len())
+	}
+	for i, h := range resp.Results {
+		if h.ID != i+1 {
+			t.Fatalf("hotel %d has ID %d, expected %d", i, h.ID, i+1)
+		}
+	}
+}
+
+func TestMaxAccumulatedResults(t *testing.T) {
+	defer func(max int) { MaxAccumulatedResults = max }(MaxAccumulatedResults)
+	MaxAccumulatedResults = 3
+
+	req := &SearchResultsRequest{SearchID: 42, Limit: 2}
+	resp, err := pagedMockAPI([]int{1, 2, 3, 4, 5}, nil).FetchAllSearchResults(context.Background(), req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Len() != 3 || !resp.Truncated {
+		t.Fatalf("got %d hotels (truncated %v), expected 3 truncated", resp.Len(), resp.Truncated)
+	}
+
+	resp, err = pagedMockAPI([]int{1, 2, 3}, nil).FetchAllSearchResults(context.Background(), req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Len() != 3 || resp.Truncated {
+		t.Fatalf("got %d hotels (truncated %v), expected all 3", resp.Len(), resp.Truncated)
+	}
+}
+
+func TestRoomPriceWithTax(t *testing.T) {
+	cases := []struct {
+		room             Room
+		withTax, without float64
+	}{
+		// Taxes are included into Total.
+		{Room{Price: 100, Tax: 12.5, Total: 113}, 113, 100},
+		// Total is not provided.
+		{Room{Price: 100, Tax: 12.5}, 112.5, 100},
+		// Total excludes taxes.
+		{Room{Price: 100, Tax: 12.5, Total: 100}, 112.5, 100},
+		// Price is not provided.
+		{Room{Tax: 12.5, Total: 113}, 113, 100.5},
+		{Room{Price: 93, Total: 93}, 93, 93},
+	}
+	for _, c := range cases {
+		if got := c.room.PriceWithTax(); got != c.withTax {
+			t.Fatalf("PriceWithTax of %+v is %f, expected %f", c.room, got, c.withTax)
+		}
+		if got := c.room.PriceWithoutTax(); got != c.without {
+			t.Fatalf("PriceWithoutTax of %+v is %f, expected %f", c.room, got, c.without)
+		}
+	}
+
+	h, _ := loadSearchResults(t).HotelByID(716111)
+	if h.Rooms[0].PriceWithTax() != 194 || h.Rooms[0].PriceWithoutTax() != 194 {
+		t.Fatal("unexpected price of room from saved response")
+	}
+}
+
+func TestSearchResultsAgencies(t *testing.T) {
+	resp := new(SearchResults)
+	err := ffjson.NewDecoder().Decode([]byte(`{"result": [
+		{"id": 1, "rooms": [
+			{"agencyId": "booking", "agencyName": "Booking.com", "price": 100},
+			{"agencyId": "ostrovok", "agencyName": "Ostrovok", "price": 90}
+		]},
+		{"id": 2, "rooms": [
+			{"agencyId": "booking", "agencyName": "Booking.com", "price": 120}
+		]}
+	]}`), resp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	agencies := resp.Agencies()
+	if len(agencies) != 2 || agencies["booking"] != 2 || agencies["ostrovok"] != 1 {
+		t.Fatalf("got agencies %v", agencies)
+	}
+	rooms := resp.RoomsByAgency("booking")
+	if len(rooms) != 2 || rooms[0].Price != 100 || rooms[1].Price != 120 {
+		t.Fatalf("got %d booking rooms, expected 2 in order of hotels", len(rooms))
+	}
+	if rooms := resp.RoomsByAgency("expedia"); len(rooms) != 0 {
+		t.Fatalf("got %d rooms of unknown agency", len(rooms))
+	}
+}
+
+func TestSearchResultsStats(t *testing.T) {
+	results := func(hotels ...SearchResult) *SearchResults {
+		return &SearchResults{Results: hotels}
+	}
+	cases := []struct {
+		resp         *SearchResults
+		score, price float64
+	}{
+		{results(), 0, 0},
+		{results(SearchResult{GuestScore: 80, Price: 100}), 80, 100},
+		// Hotels without score and price are skipped.
+		{results(SearchResult{GuestScore: 70, Price: 50}, SearchResult{}, SearchResult{GuestScore: 90, Price: 30}), 80, 40},
+		{results(SearchResult{Price: 30}, SearchResult{Price: 10}, SearchResult{Price: 20}), 0, 20},
+		{loadSearchResults(t), 1253.0 / 17, 35},
+	}
+	for i, c := range cases {
+		if got := c.resp.AverageGuestScore(); got != c.score {
+			t.Fatalf("case %d: average guest score is %f, expected %f", i, got, c.score)
+		}
+		if got := c.resp.MedianPrice(); got != c.price {
+			t.Fatalf("case %d: median price is %f, expected %f", i, got, c.price)
+		}
+	}
+}
+
+func TestSearchResultsRoomFilters(t *testing.T) {
+	var query url.Values
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return mockResponse(`{"status": "ok", "result": [
+			{"id": 1, "rooms": [
+				{"price": 100, "options": {"breakfast": true}},
+				{"price": 120, "options": {"breakfast": true, "freeWifi": true}}
+			]},
+			{"id": 2, "rooms": [{"price": 90, "options": {"freeWifi": true}}]}
+		]}`), nil
+	})
+	req := &SearchResultsRequest{SearchID: 1, RoomsCount: 5, Breakfast: true, FreeWifi: true}
+	resp, err := api.FetchSearchResults(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Len() != 1 || len(resp.Results[0].Rooms) != 1 || resp.Results[0].Rooms[0].Price != 120 {
+		t.Fatalf("got results %+v, expected only room with breakfast and wifi", resp.Results)
+	}
+	if query.Get("breakfast") != "" || query.Get("freeWifi") != "" || query.Get("roomsCount") != "5" {
+		t.Fatalf("got query %v, room filters are not supported by API", query)
+	}
+	if resp, _ = api.FetchSearchResults(&SearchResultsRequest{SearchID: 1}); resp.RoomCount() != 3 {
+		t.Fatalf("got %d rooms without filters, expected all", resp.RoomCount())
+	}
+
+	invalid := []SearchResultsRequest{
+		{},
+		{SearchID: 1, Limit: -1},
+		{SearchID: 1, SortBy: "distance"},
+		{SearchID: 1, SortAsc: 2},
+		{SearchID: 1, RoomsCount: -1, Refundable: true},
+	}
+	for _, req := range invalid {
+		if _, err := api.FetchSearchResults(&req); err == nil {
+			t.Fatalf("request %+v should be rejected", req)
+		}
+	}
+}
+
+func TestAffiliateHost(t *testing.T) {
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		return mockResponse(`{"status": "ok", "result": [{"id": 1,
+			"fullUrl": "http://search.hotellook.com/?language=en&marker=62597&hotelId=1",
+			"url": "/search/?marker=62597&hotelId=1",
+			"rooms": [{"fullBookingURL": "http://search.hotellook.com/r?host=v2%3A62597&currency=USD&roomId=0",
+				"bookingURL": "/r/?host=v2%3A62597&roomId=0"}]}]}`), nil
+	})
+	api.SetAffiliateHost("search.hotellook.ru")
+	resp, err := api.FetchSearchResults(&SearchResultsRequest{SearchID: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	h := resp.Results[0]
+	if h.FullURL != "http://search.hotellook.ru/?language=en&marker=62597&hotelId=1" {
+		t.Fatalf("got hotel link %s", h.FullURL)
+	}
+	if h.Rooms[0].FullBookingURL != "http://search.hotellook.ru/r?host=v2%3A62597&currency=USD&roomId=0" {
+		t.Fatalf("got booking link %s", h.Rooms[0].FullBookingURL)
+	}
+	if h.URL != "/search/?marker=62597&hotelId=1" || h.Rooms[0].BookingURL != "/r/?host=v2%3A62597&roomId=0" {
+		t.Fatal("relative links should be kept")
+	}
+	if resp.Currency != "USD" {
+		t.Fatalf("currency is %q after rewriting, expected USD", resp.Currency)
+	}
+}
+
+func TestSearchResultsSortByPrice(t *testing.T) {
+	resp := &SearchResults{Results: []SearchResult{
+		{ID: 1, MinPriceTotal: 300, MaxPricePerNight: 60},
+		{ID: 2, MinPriceTotal: 0, MaxPricePerNight: 90},
+		{ID: 3, MinPriceTotal: 150, MaxPricePerNight: 0},
+		{ID: 4, MinPriceTotal: 300, MaxPricePerNight: 40},
+		{ID: 5, MinPriceTotal: 120, MaxPricePerNight: 40},
+	}}
+	cases := []struct {
+		sort func()
+		ids  []int
+	}{
+		{resp.SortByMinTotal, []int{5, 3, 1, 4, 2}},
+		// Stable, so order of previous sort is kept for equal prices.
+		{resp.SortByMaxPricePerNight, []int{5, 4, 1, 2, 3}},
+	}
+	for i, c := range cases {
+		c.sort()
+		for j, id := range c.ids {
+			if resp.Results[j].ID != id {
+				t.Fatalf("case %d: hotel %d has ID %d, expected order %v", i, j, resp.Results[j].ID, c.ids)
+			}
+		}
+	}
+}
+
+func TestSearchResultsSortByValue(t *testing.T) {
+	resp := &SearchResults{Results: []SearchResult{
+		{ID: 1, Price: 300, Stars: 3},                 // 100 per star
+		{ID: 2, Price: 200},                           // no rating
+		{ID: 3, Price: 400, Stars: 5},                 // 80
+		{ID: 4, Stars: 4},                             // no price
+		{ID: 5, Price: 270, GuestScore: 90},           // 60 by guest score
+		{ID: 6, Price: 150, Stars: 1, GuestScore: 95}, // 150, stars win
+		{ID: 7, Price: 240, Stars: 3},                 // 80, after hotel 3
+	}}
+	resp.SortByValue()
+	expected := []int{5, 3, 7, 1, 6, 2, 4}
+	for i, id := range expected {
+		if resp.Results[i].ID != id {
+			t.Fatalf("hotel %d has ID %d, expected order %v", i, resp.Results[i].ID, expected)
+		}
+	}
+}
+
+func TestSearchResultPhotoURLs(t *testing.T) {
+	api := NewAPI(marker)
+	h := &SearchResult{ID: 716111, PhotoCount: 3}
+	cases := []struct{ max, count int }{{0, 3}, {2, 2}, {3, 3}, {10, 3}}
+	for _, c := range cases {
+		if urls := h.PhotoURLs(api, PhotoLarge, c.max); len(urls) != c.count {
+			t.Fatalf("PhotoURLs with max %d returns %d links, expected %d", c.max, len(urls), c.count)
+		}
+	}
+	urls := h.PhotoURLs(api, PhotoLarge, 2)
+	if urls[1] != "https://photo.hotellook.com/image_v2/limit/h716111_1/1024/768.jpg" {
+		t.Fatalf("second photo link is %s", urls[1])
+	}
+	if urls := (&SearchResult{ID: 1}).PhotoURLs(api, PhotoLarge, 5); urls != nil {
+		t.Fatalf("hotel without photos has links %v", urls)
+	}
+}
+
+func TestWaitForSearchResults(t *testing.T) {
+	polls := 0
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		polls++
+		if polls < 3 {
+			resp := mockResponse(`{"status":"error","errorCode":4,"message":"Search is not finished."}`)
+			resp.StatusCode = http.StatusConflict
+			return resp, nil
+		}
+		return mockResponse(`{"status":"ok","result":[{"id":1,"rooms":[{},{}]},{"id":2,"rooms":[{}]}]}`), nil
+	})
+	c := newFakeClock()
+	api.setClock(c)
+
+	var statuses []string
+	var partials []*SearchResults
+	done := make(chan error)
+	var resp *SearchResults
+	go func() {
+		var err error
+		resp, err = api.WaitForSearchResults(context.Background(), &SearchResultsRequest{SearchID: 1}, &WaitOptions{
+			Interval: time.Second,
+			OnProgress: func(status string, partial *SearchResults) {
+				statuses = append(statuses, status)
+				partials = append(partials, partial)
+			},
+		})
+		done <- err
+	}()
+	for i := 0; i < 2; i++ {
+		if d := <-c.waits; d != time.Second {
+			t.Fatalf("waits %v between polls, expected 1s", d)
+		}
+		c.fire <- c.now
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if polls != 3 || resp.Len() != 2 {
+		t.Fatalf("got %d hotels after %d polls, expected 2 after 3", resp.Len(), polls)
+	}
+	if resp.RoomCount() != 3 {
+		t.Fatalf("got %d room offers, expected 3", resp.RoomCount())
+	}
+	if len(statuses) != 3 || statuses[0] != "Search is not finished." || statuses[2] != "ok" {
+		t.Fatalf("progress got statuses %q, expected one per poll", statuses)
+	}
+	if partials[0] != nil || partials[2] != resp {
+		t.Fatal("progress should get results only when they are returned")
+	}
+}
+
+func TestFetchSearchResultsTimeout(t *testing.T) {
+	finished := false
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if finished {
+			return mockResponse(`{"status":"ok","result":[{"id":1}]}`), nil
+		}
+		resp := mockResponse(`{"status":"error","errorCode":4,"message":"Search is not finished."}`)
+		resp.StatusCode = http.StatusConflict
+		return resp, nil
+	})
+	// Delay between polls never ends.
+	api.setClock(newFakeClock())
+	req := &SearchResultsRequest{SearchID: 1}
+
+	resp, err := api.FetchSearchResultsTimeout(context.Background(), req, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !resp.Partial || !resp.Empty() {
+		t.Fatalf("got %+v, expected empty partial results", resp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = api.FetchSearchResultsTimeout(ctx, req, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected deadline of caller context", err)
+	}
+
+	finished = true
+	resp, err = api.FetchSearchResultsTimeout(context.Background(), req, time.Second)
+	if err != nil || resp.Partial || resp.Len() != 1 {
+		t.Fatalf("got %+v, %v, expected finished search", resp, err)
+	}
+}
+
+func TestDecodeSearchResults(t *testing.T) {
+	resp, err := DecodeSearchResults(strings.NewReader(`{"status":"ok","result":[
+		{"id":1,"rooms":[{"fullBookingURL":"http://search.hotellook.com/r?currency=eur"}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Status != "ok" || resp.Len() != 1 || resp.Currency != "EUR" {
+		t.Fatalf("got %+v, expected one hotel in EUR", resp)
+	}
+
+	if _, err = DecodeSearchResults(strings.NewReader(`{"status":`)); err == nil {
+		t.Fatal("truncated JSON should not be decoded")
+	}
+	if _, err = DecodeSearchResults(iotest.ErrReader(errors.New("read failed"))); err == nil {
+		t.Fatal("error of reader should be returned")
+	}
+}
+
+func TestDedupResults(t *testing.T) {
+	// Pages overlap after hotels 2 and 3 shifted with new prices.
+	page1 := []SearchResult{{ID: 1, Price: 100}, {ID: 2, Price: 120}, {ID: 3, Price: 90}}
+	page2 := []SearchResult{{ID: 2, Price: 110}, {ID: 3, Price: 95}, {ID: 4}, {ID: 4, Price: 80}, {ID: 5, Price: 70}}
+	deduped := DedupResults(append(page1, page2...))
+	expected := "[{1 100} {2 110} {3 90} {4 80} {5 70}]"
+	var got []string
+	for _, h := range deduped {
+		got = append(got, fmt.Sprintf("{%d %d}", h.ID, h.Price))
+	}
+	if fmt.Sprint(got) != expected {
+		t.Fatalf("DedupResults returns %v, expected %s", got, expected)
+	}
+	if DedupResults(nil) != nil {
+		t.Fatal("DedupResults of nothing should be nil")
+	}
+}
+
+func TestFetchAllSearchResultsCheapest(t *testing.T) {
+	// Hotel 2 is repeated on the second page with lower price.
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("offset") == "" {
+			return mockResponse(`{"status":"ok","result":[{"id":1,"price":100},{"id":2,"price":150}]}`), nil
+		}
+		return mockResponse(`{"status":"ok","result":[{"id":2,"price":130}]}`), nil
+	})
+	resp, err := api.FetchAllSearchResults(context.Background(), &SearchResultsRequest{SearchID: 42, Limit: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Len() != 2 || resp.Results[1].ID != 2 || resp.Results[1].Price != 130 {
+		t.Fatalf("got %+v, expected hotel 2 with the lowest price", resp.Results)
+	}
+}
+
+func TestPageSearchResults(t *testing.T) {
+	api := pagedMockAPI([]int{1, 2, 3, 4, 5}, nil)
+	cases := []struct {
+		limit, offset      int
+		items, next, total int
+	}{
+		{2, 0, 2, 2, 2},
+		{2, 2, 2, 4, 4},
+		{2, 4, 1, 0, 5},
+		{5, 0, 5, 5, 5},
+		{2, 6, 0, 0, 6},
+		{0, 1, 4, 0, 5},
+	}
+	for _, c := range cases {
+		page, err := api.PageSearchResults(context.Background(), &SearchResultsRequest{
+			SearchID: 42,
+			Limit:    c.limit,
+			Offset:   c.offset,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(page.Items) != c.items || page.NextOffset != c.next || page.Total != c.total {
+			t.Fatalf("page with limit %d, offset %d has %d items, next offset %d, total %d; expected %d, %d, %d",
+				c.limit, c.offset, len(page.Items), page.NextOffset, page.Total, c.items, c.next, c.total)
+		}
+	}
+
+	if _, err := api.PageSearchResults(context.Background(), &SearchResultsRequest{}); !errors.Is(err, ErrEmptySearchID) {
+		t.Fatalf("got %v, expected ErrEmptySearchID", err)
+	}
+}
@@ -0,0 +1,117 @@
+package hotellook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// Fetches city list like Cities, but decodes cities one by one and passes
+// them to fn, so the whole list is never held in memory. Stops reading
+// as soon as fn returns false or ctx is done.
+func (this *API) CitiesStream(ctx context.Context, fn func(Cities) bool) error {
+	if err := this.checkAccess(); err != nil {
+		return err
+	}
+	const endpoint = StaticCities
+	if err := this.waitForRateLimit(ctx, citiesMinRemains); err != nil {
+		return err
+	}
+	return this.stream(ctx, endpoint, this.withSignature(nil), "", func(d *json.Decoder) (bool, error) {
+		var c Cities
+		if err := d.Decode(&c); err != nil {
+			return false, err
+		}
+		return fn(c), nil
+	})
+}
+
+// Fetches hotels of location like FetchHotelList, but decodes hotels one
+// by one and passes them to fn, so the whole list is never held in memory.
+// Stops reading as soon as fn returns false or ctx is done.
+func (this *API) FetchHotelListStream(ctx context.Context, locationID string, fn func(Hotel) bool) error {
+	if err := this.checkAccess(); err != nil {
+		return err
+	}
+	query := this.withSignature(map[string]string{"locationId": locationID})
+	return this.stream(ctx, EndpointHotels, query, "hotels", func(d *json.Decoder) (bool, error) {
+		var h Hotel
+		if err := d.Decode(&h); err != nil {
+			return false, err
+		}
+		return fn(h), nil
+	})
+}
+
+// Reads JSON array from the endpoint, next is called for every element
+// and should decode it. If field is set, response is an object and the array
+// is its field. Response body is closed on return.
+func (this *API) stream(ctx context.Context, endpoint, query, field string, next func(*json.Decoder) (bool, error)) error {
+	r, err := this.do(ctx, endpoint, query, "")
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	d := json.NewDecoder(r.Body)
+	if this.strict() {
+		d.DisallowUnknownFields()
+	}
+	if field != "" {
+		err = seekField(d, field)
+	}
+	if err == nil {
+		err = decodeArray(ctx, d, next)
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = ErrTruncatedResponse
+	}
+	if err != nil && err == ctx.Err() {
+		return err
+	}
+	this.countRequest(err)
+	return wrapErr(endpoint, err)
+}
+
+// Reads object up to value of field, other fields are skipped.
+func seekField(d *json.Decoder, field string) error {
+	if t, err := d.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return ErrInvalidJSON
+	}
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if t == field {
+			return nil
+		}
+		var skipped json.RawMessage
+		if err = d.Decode(&skipped); err != nil {
+			return err
+		}
+	}
+	// Error responses don't have the field.
+	return ErrNoAccess
+}
+
+func decodeArray(ctx context.Context, d *json.Decoder, next func(*json.Decoder) (bool, error)) error {
+	if t, err := d.Token(); err != nil {
+		return err
+	} else if t != json.Delim('[') {
+		// Static endpoints respond with an object when access is denied.
+		return ErrNoAccess
+	}
+	for d.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ok, err := next(d)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
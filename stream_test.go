@@ -0,0 +1,105 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Body which remembers whether it was closed.
+type trackingBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCitiesStream(t *testing.T) {
+	const cities = `[{"id":"1","code":"MOW"},{"id":"2","code":"LED"},{"id":"3","code":"KHV"}]`
+	var body *trackingBody
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		body = &trackingBody{Reader: strings.NewReader(cities)}
+		resp := mockResponse("")
+		resp.Body = body
+		return resp, nil
+	})
+
+	var codes []string
+	err := api.CitiesStream(context.Background(), func(c Cities) bool {
+		codes = append(codes, c.Code)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(codes, ",") != "MOW,LED,KHV" || !body.closed {
+		t.Fatalf("got %v, closed=%v", codes, body.closed)
+	}
+	if s := api.Stats(); s.Total != 1 || s.Errors != 0 {
+		t.Fatalf("got stats %+v, expected one successful request", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	codes = nil
+	err = api.CitiesStream(ctx, func(c Cities) bool {
+		codes = append(codes, c.Code)
+		cancel()
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("CitiesStream returns %v after cancel, expected context.Canceled", err)
+	}
+	if len(codes) != 1 || !body.closed {
+		t.Fatalf("got %d cities after cancel, closed=%v", len(codes), body.closed)
+	}
+	if s := api.Stats(); s.Total != 1 {
+		t.Fatalf("got %d requests, cancelled stream should not be counted", s.Total)
+	}
+}
+
+func TestFetchHotelListStream(t *testing.T) {
+	const hotels = `{"gen_timestamp": 1481371200, "hotels": [
+		{"id": 1, "name": {"en": "Parus"}, "photos": [{"url": "a", "width": 640, "height": 480}]},
+		{"id": 2, "name": {"en": "Metropol"}},
+		{"id": 3, "name": {"en": "Cosmos"}}
+	], "count": 3}`
+	var body *trackingBody
+	var location string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		location = r.URL.Query().Get("locationId")
+		body = &trackingBody{Reader: strings.NewReader(hotels)}
+		resp := mockResponse("")
+		resp.Body = body
+		return resp, nil
+	})
+
+	var names []string
+	err := api.FetchHotelListStream(context.Background(), "12153", func(h Hotel) bool {
+		names = append(names, h.Name.EN)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(names, ",") != "Parus,Metropol,Cosmos" || location != "12153" || !body.closed {
+		t.Fatalf("got %v for location %q, closed=%v", names, location, body.closed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	names = nil
+	err = api.FetchHotelListStream(ctx, "12153", func(h Hotel) bool {
+		names = append(names, h.Name.EN)
+		cancel()
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("FetchHotelListStream returns %v after cancel, expected context.Canceled", err)
+	}
+	if len(names) != 1 || !body.closed {
+		t.Fatalf("got %d hotels after cancel, closed=%v", len(names), body.closed)
+	}
+}
@@ -0,0 +1,116 @@
+package hotellook
+
+import (
+	"fmt"
+	"net"
+)
+
+// Maximum age of child in search. HotelLook documents children as 2-18
+// and infants as 0-2 years, but search has no infants field, so ages of
+// children are checked only against 0-MaxChildAge. Price requests have
+// no ages at all.
+const MaxChildAge = 18
+
+// Checks that location is set and at most one of HotelID and Hotel is used.
+// PriceRequest has no ages, so it checks only that numbers of children
+// (2-18 years) and infants (0-2 years) make sense.
+func (this *PriceRequest) Validate() error {
+	if this.Location == "" && this.LocationID == 0 {
+		return ErrMissingParams
+	}
+	if this.HotelID != 0 && this.Hotel != "" {
+		return ErrConflictingParams
+	}
+	if this.Adults < 0 {
+		return fmt.Errorf("Invalid adults count %d", this.Adults)
+	}
+	if this.Children < 0 {
+		return fmt.Errorf("Invalid children count %d", this.Children)
+	}
+	if this.Infants < 0 {
+		return fmt.Errorf("Invalid infants count %d", this.Infants)
+	}
+	if err := validateStars(this.Stars); err != nil {
+		return err
+	}
+	return validateIP(this.CustomerIP)
+}
+
+// Checks children count and ages. Search has no separate infants field,
+// so infants (0-2 years) are passed as children and ages 0-18 are valid.
+// Every room of multi-room search is checked the same way.
+func (this *SearchRequest) Validate() error {
+	if len(this.Rooms) == 0 {
+		if err := validateChildren(this.ChildrenCount, this.ChildAges); err != nil {
+			return err
+		}
+		return validateIP(this.CustomerIP)
+	}
+	if this.AdultsCount != 0 || this.ChildrenCount != 0 {
+		return ErrConflictingParams
+	}
+	for i, room := range this.Rooms {
+		if room.AdultsCount < 1 {
+			return fmt.Errorf("Invalid adults count %d of room %d", room.AdultsCount, i+1)
+		}
+		if err := validateChildren(room.ChildrenCount, room.ChildAges); err != nil {
+			return fmt.Errorf("Room %d: %v", i+1, err)
+		}
+	}
+	return validateIP(this.CustomerIP)
+}
+
+func validateChildren(count int, ages [3]int) error {
+	if count < 0 || count > len(ages) {
+		return fmt.Errorf("Invalid children count %d, expected 0-%d", count, len(ages))
+	}
+	for i := 0; i < count; i++ {
+		if age := ages[i]; age < 0 || age > MaxChildAge {
+			return fmt.Errorf("Age of child %d is %d, expected 0-%d", i+1, age, MaxChildAge)
+		}
+	}
+	return nil
+}
+
+// Customer IP is optional, but if it's set, it should be IPv4 or IPv6 address.
+func validateIP(ip net.IP) error {
+	if ip != nil && len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return fmt.Errorf("Invalid customer IP %v", ip)
+	}
+	return nil
+}
+
+var searchSortFields = map[string]bool{
+	"": true, "popularity": true, "price": true, "name": true, "guestScore": true, "stars": true,
+}
+
+// Checks that search ID is positive, sorting field is known and numbers are not negative.
+func (this *SearchResultsRequest) Validate() error {
+	if this.SearchID <= 0 {
+		return ErrEmptySearchID
+	}
+	if this.Limit < 0 {
+		return fmt.Errorf("Invalid limit %d", this.Limit)
+	}
+	if this.Offset < 0 {
+		return fmt.Errorf("Invalid offset %d", this.Offset)
+	}
+	if !searchSortFields[this.SortBy] {
+		return fmt.Errorf("Unknown sort field %q", this.SortBy)
+	}
+	if this.SortAsc != 0 && this.SortAsc != 1 && this.SortAsc != -1 {
+		return fmt.Errorf("Invalid sortAsc %d, expected 1 or -1", this.SortAsc)
+	}
+	if this.RoomsCount < 0 {
+		return fmt.Errorf("Invalid rooms count %d", this.RoomsCount)
+	}
+	return validateStars(this.Stars)
+}
+
+// Stars filter is optional, zero means any hotel.
+func validateStars(stars int) error {
+	if stars < 0 || stars > 5 {
+		return fmt.Errorf("Invalid stars %d, expected 1-5", stars)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package hotellook
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPriceRequestValidate(t *testing.T) {
+	valid := []PriceRequest{
+		{Location: "MOW"},
+		{LocationID: 12153, HotelID: 716111},
+		{Location: "Saint Petersburg", Hotel: "Parus"},
+		{Location: "MOW", Adults: 2, Children: 1, Infants: 1},
+		{Location: "MOW", Stars: 5},
+	}
+	for _, req := range valid {
+		if err := req.Validate(); err != nil {
+			t.Fatalf("%+v: %s", req, err.Error())
+		}
+	}
+	invalid := []PriceRequest{
+		{Location: "MOW", Adults: -1},
+		{Location: "MOW", Children: -1},
+		{Location: "MOW", Infants: -2},
+		{Location: "MOW", Stars: 6},
+		{Location: "MOW", Stars: -1},
+	}
+	for _, req := range invalid {
+		if req.Validate() == nil {
+			t.Fatalf("%+v should be invalid", req)
+		}
+	}
+
+	if err := (&PriceRequest{HotelID: 716111}).Validate(); err != ErrMissingParams {
+		t.Fatalf("request without location returns %v, expected ErrMissingParams", err)
+	}
+	conflicting := []PriceRequest{
+		{Location: "MOW", HotelID: 716111, Hotel: "Parus"},
+		{LocationID: 12153, HotelID: 716111, Hotel: "Parus"},
+	}
+	for _, req := range conflicting {
+		if err := req.Validate(); err != ErrConflictingParams {
+			t.Fatalf("%+v returns %v, expected ErrConflictingParams", req, err)
+		}
+	}
+}
+
+func TestSearchRequestValidate(t *testing.T) {
+	valid := []SearchRequest{
+		{},
+		{ChildrenCount: 1, ChildAges: [3]int{0}},
+		{ChildrenCount: 2, ChildAges: [3]int{2, MaxChildAge}},
+		// Ages of children which are not counted are ignored.
+		{ChildrenCount: 1, ChildAges: [3]int{5, 99, -1}},
+	}
+	for _, req := range valid {
+		if err := req.Validate(); err != nil {
+			t.Fatalf("%+v: %s", req, err.Error())
+		}
+	}
+	invalid := []SearchRequest{
+		{ChildrenCount: -1},
+		{ChildrenCount: 4},
+		{ChildrenCount: 1, ChildAges: [3]int{-1}},
+		{ChildrenCount: 3, ChildAges: [3]int{5, 10, MaxChildAge + 1}},
+	}
+	for _, req := range invalid {
+		if req.Validate() == nil {
+			t.Fatalf("%+v should be invalid", req)
+		}
+	}
+}
+
+func TestStarsFilter(t *testing.T) {
+	var sent []string
+	api := mockAPI(func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.URL.Query()["stars"]...)
+		if r.URL.Path == "/api/v2/cache.json" {
+			return mockResponse(`[{"hotelId":1,"stars":4},{"hotelId":2,"stars":3}]`), nil
+		}
+		return mockResponse(`{"status":"ok","result":[{"id":1,"stars":3},{"id":2,"stars":5},{"id":3,"stars":3}]}`), nil
+	})
+	prices, err := api.Price(&PriceRequest{Location: "MOW", CheckIn: "2016-12-10", CheckOut: "2016-12-17", Stars: 4})
+	if err != nil || len(*prices) != 1 || (*prices)[0].HotelID != 1 {
+		t.Fatalf("got prices %+v, %v, expected only 4-star hotel", prices, err)
+	}
+	resp, err := api.FetchSearchResults(&SearchResultsRequest{SearchID: 1, Stars: 3})
+	if err != nil || resp.Len() != 2 || resp.Results[1].ID != 3 {
+		t.Fatalf("got results %+v, %v, expected only 3-star hotels", resp, err)
+	}
+	if resp, _ = api.FetchSearchResults(&SearchResultsRequest{SearchID: 1}); resp.Len() != 3 {
+		t.Fatalf("got %d results without stars filter", resp.Len())
+	}
+	// Page is full before filtering, so it's not the last one.
+	page, err := api.PageSearchResults(context.Background(), &SearchResultsRequest{SearchID: 1, Limit: 3, Stars: 5})
+	if err != nil || len(page.Items) != 1 || page.NextOffset != 3 {
+		t.Fatalf("got page %+v, %v, expected one hotel and next offset 3", page, err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent stars %q, API doesn't support the param", sent)
+	}
+
+	if _, err := api.FetchSearchResults(&SearchResultsRequest{SearchID: 1, Stars: 7}); err == nil {
+		t.Fatal("search results with 7 stars should not be requested")
+	}
+}
+
+func TestSearchResultsRequestValidate(t *testing.T) {
+	valid := []SearchResultsRequest{
+		{SearchID: 1},
+		{SearchID: 1, Limit: 10, Offset: 20, SortBy: "price", SortAsc: -1},
+		{SearchID: 1, SortBy: "guestScore", SortAsc: 1, RoomsCount: 2, Stars: 5},
+	}
+	for _, req := range valid {
+		if err := req.Validate(); err != nil {
+			t.Fatalf("%+v: %s", req, err.Error())
+		}
+	}
+
+	invalid := []struct {
+		req SearchResultsRequest
+		msg string
+	}{
+		{SearchResultsRequest{SearchID: 1, Limit: -1}, "Invalid limit -1"},
+		{SearchResultsRequest{SearchID: 1, Offset: -5}, "Invalid offset -5"},
+		{SearchResultsRequest{SearchID: 1, SortBy: "distance"}, `Unknown sort field "distance"`},
+		{SearchResultsRequest{SearchID: 1, SortAsc: 2}, "Invalid sortAsc 2"},
+		{SearchResultsRequest{SearchID: 1, RoomsCount: -1}, "Invalid rooms count -1"},
+		{SearchResultsRequest{SearchID: 1, Stars: 6}, "Invalid stars 6"},
+	}
+	for _, c := range invalid {
+		err := c.req.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Fatalf("%+v returns %v, expected %q", c.req, err, c.msg)
+		}
+	}
+	if err := (&SearchResultsRequest{Limit: 10}).Validate(); err != ErrEmptySearchID {
+		t.Fatalf("request without search ID returns %v, expected ErrEmptySearchID", err)
+	}
+	if err := (&SearchResultsRequest{SearchID: -1}).Validate(); err != ErrEmptySearchID {
+		t.Fatalf("request with search ID -1 returns %v, expected ErrEmptySearchID", err)
+	}
+}